@@ -10,6 +10,7 @@ import (
 // INTERFACES
 // ==========
 type Node interface {
+    Pos() token.Position
     String() string
 }
 type Statement interface {
@@ -27,6 +28,12 @@ type Expression interface {
 type Program struct {
     Statements []Statement
 }
+func (self *Program) Pos() token.Position {
+    if len(self.Statements) > 0 {
+        return self.Statements[0].Pos()
+    }
+    return token.Position{}
+}
 func (self *Program) String() string {
     var out bytes.Buffer
 
@@ -40,16 +47,29 @@ func (self *Program) String() string {
 // ==========
 // STATEMENTS
 // ==========
+// Destructuring lets, like let a, b be f(), leave Identifier nil and bind
+// every name in Targets instead.
 type LetStatement struct {
     Identifier *Identifier
+    Targets []*Identifier
     Expression Expression
+    Position token.Position
 }
 func (self *LetStatement) statement() {}
+func (self *LetStatement) Pos() token.Position { return self.Position }
 func (self *LetStatement) String() string {
     var out bytes.Buffer
 
     out.WriteString("let ")
-    out.WriteString(self.Identifier.String())
+    if self.Identifier != nil {
+        out.WriteString(self.Identifier.String())
+    }
+    for i, target := range self.Targets {
+        out.WriteString(target.String())
+        if i < len(self.Targets) - 1 {
+            out.WriteString(", ")
+        }
+    }
     out.WriteString(" = ")
     out.WriteString(self.Expression.String())
     out.WriteString(";")
@@ -59,8 +79,10 @@ func (self *LetStatement) String() string {
 
 type ReturnStatement struct {
     Expression Expression
+    Position token.Position
 }
 func (self *ReturnStatement) statement() {}
+func (self *ReturnStatement) Pos() token.Position { return self.Position }
 func (self *ReturnStatement) String() string {
     var out bytes.Buffer
 
@@ -73,16 +95,20 @@ func (self *ReturnStatement) String() string {
 
 type ExpressionStatement struct {
     Expression Expression
+    Position token.Position
 }
 func (self *ExpressionStatement) statement() {}
+func (self *ExpressionStatement) Pos() token.Position { return self.Position }
 func (self *ExpressionStatement) String() string {
     return self.Expression.String()
 }
 
 type BlockStatement struct {
     Statements []Statement
+    Position token.Position
 }
 func (self *BlockStatement) statement() {}
+func (self *BlockStatement) Pos() token.Position { return self.Position }
 func (self *BlockStatement) String() string {
     var out bytes.Buffer
 
@@ -96,8 +122,10 @@ func (self *BlockStatement) String() string {
 type MutStatement struct {
     Identifier Expression
     Expression Expression
+    Position token.Position
 }
 func (self *MutStatement) statement() {}
+func (self *MutStatement) Pos() token.Position { return self.Position }
 func (self *MutStatement) String() string {
     var out bytes.Buffer
 
@@ -112,12 +140,17 @@ func (self *MutStatement) String() string {
 type ExeStatement struct {
     Function Expression
     Arguments []Expression
+    Position token.Position
 }
 func (self *ExeStatement) statement() {}
+func (self *ExeStatement) Pos() token.Position { return self.Position }
 func (self *ExeStatement) String() string {
     var out bytes.Buffer
 
     out.WriteString(self.Function.String())
+    if _, ok := self.Function.(*DotExpression); ok {
+        return out.String()
+    }
     out.WriteString("(")
     for i, argument := range self.Arguments {
         out.WriteString(argument.String())
@@ -130,15 +163,30 @@ func (self *ExeStatement) String() string {
     return out.String()
 }
 
+// use "shapes/geom" binds the module in shapes/geom.kimchi to geom
+type UseStatement struct {
+    Path string
+    Name string
+    Position token.Position
+}
+func (self *UseStatement) statement() {}
+func (self *UseStatement) Pos() token.Position { return self.Position }
+func (self *UseStatement) String() string { return "use \"" + self.Path + "\"" }
+
 
 // ===========
 // EXPRESSIONS
 // ===========
+// Function parameters can also have a default value or be variadic
 type Identifier struct {
     Name string
     Type *TypeLiteral
+    Default Expression
+    Variadic bool
+    Position token.Position
 }
 func (self *Identifier) expression() {}
+func (self *Identifier) Pos() token.Position { return self.Position }
 func (self *Identifier) String() string {
     var out bytes.Buffer
 
@@ -147,23 +195,35 @@ func (self *Identifier) String() string {
     return out.String()
 }
 
+// A type annotation. Collections hold their element types in Subtypes,
+// and function types hold their parameter types in Subtypes and the
+// return type in Return.
 type TypeLiteral struct {
     Type token.Token
-    Subtypes []token.Token
+    Subtypes []*TypeLiteral
+    Return *TypeLiteral
+    Position token.Position
 }
 func (self *TypeLiteral) expression() {}
+func (self *TypeLiteral) Pos() token.Position { return self.Position }
 func (self *TypeLiteral) String() string {
     var out bytes.Buffer
 
     out.WriteString(self.Type.Literal)
 
-    if len(self.Subtypes) > 0 {
-        out.WriteString("<")
-        for _, subtype := range self.Subtypes {
-            out.WriteString(subtype.Literal)
-            out.WriteString(", ")
+    if len(self.Subtypes) > 0 || self.Return != nil {
+        out.WriteString("(")
+        for i, subtype := range self.Subtypes {
+            out.WriteString(subtype.String())
+            if i < len(self.Subtypes) - 1 {
+                out.WriteString(", ")
+            }
         }
-        out.WriteString(">")
+        out.WriteString(")")
+    }
+    if self.Return != nil {
+        out.WriteString(": ")
+        out.WriteString(self.Return.String())
     }
 
     return out.String()
@@ -172,8 +232,10 @@ func (self *TypeLiteral) String() string {
 type PrefixExpression struct {
     Operator string
     Right Expression
+    Position token.Position
 }
 func (self *PrefixExpression) expression() {}
+func (self *PrefixExpression) Pos() token.Position { return self.Position }
 func (self *PrefixExpression) String() string {
     var out bytes.Buffer
 
@@ -189,8 +251,10 @@ type InfixExpression struct {
     Left Expression
     Operator string
     Right Expression
+    Position token.Position
 }
 func (self *InfixExpression) expression() {}
+func (self *InfixExpression) Pos() token.Position { return self.Position }
 func (self *InfixExpression) String() string {
     var out bytes.Buffer
 
@@ -209,8 +273,10 @@ type IfExpression struct {
     Condition Expression
     Consequence *BlockStatement
     Alternative *BlockStatement
+    Position token.Position
 }
 func (self *IfExpression) expression() {}
+func (self *IfExpression) Pos() token.Position { return self.Position }
 func (self *IfExpression) String() string {
     var out bytes.Buffer
 
@@ -227,16 +293,89 @@ func (self *IfExpression) String() string {
     return out.String()
 }
 
+type MatchExpression struct {
+    Subject Expression
+    Arms []*MatchArm
+    Position token.Position
+}
+func (self *MatchExpression) expression() {}
+func (self *MatchExpression) Pos() token.Position { return self.Position }
+func (self *MatchExpression) String() string {
+    var out bytes.Buffer
+
+    out.WriteString("match ")
+    out.WriteString(self.Subject.String())
+    out.WriteString(" {")
+    for i, arm := range self.Arms {
+        out.WriteString(arm.String())
+        if i < len(self.Arms) - 1 {
+            out.WriteString(", ")
+        }
+    }
+    out.WriteString("}")
+
+    return out.String()
+}
+
+// A single pattern of a match expression, with an optional guard:
+// pattern if guard: body
+type MatchArm struct {
+    Pattern Expression
+    Guard Expression
+    Body *BlockStatement
+    Position token.Position
+}
+func (self *MatchArm) String() string {
+    var out bytes.Buffer
+
+    out.WriteString(self.Pattern.String())
+    if self.Guard != nil {
+        out.WriteString(" if ")
+        out.WriteString(self.Guard.String())
+    }
+    out.WriteString(": ")
+    out.WriteString(self.Body.String())
+
+    return out.String()
+}
+
+// The rest of a list in a match pattern, like in list(first, ...rest)
+type RestPattern struct {
+    Identifier *Identifier
+    Position token.Position
+}
+func (self *RestPattern) expression() {}
+func (self *RestPattern) Pos() token.Position { return self.Position }
+func (self *RestPattern) String() string {
+    return "..." + self.Identifier.String()
+}
+
 type FunctionLiteral struct {
+    // Names of the type parameters of generic functions, like T in
+    // fn[T](xs: list(T)): T
+    TypeParameters []*Identifier
     Parameters []*Identifier
     ReturnType *TypeLiteral
     Body *BlockStatement
+    Position token.Position
 }
 func (self *FunctionLiteral) expression() {}
+func (self *FunctionLiteral) Pos() token.Position { return self.Position }
 func (self *FunctionLiteral) String() string {
     var out bytes.Buffer
 
-    out.WriteString("fn(")
+    out.WriteString("fn")
+    if len(self.TypeParameters) > 0 {
+        out.WriteString("[")
+        for i, parameter := range self.TypeParameters {
+            out.WriteString(parameter.String())
+            if i < len(self.TypeParameters) - 1 {
+                out.WriteString(", ")
+            }
+        }
+        out.WriteString("]")
+    }
+    out.WriteString("(")
     for i, parameter := range self.Parameters {
         out.WriteString(parameter.String())
         if i < len(self.Parameters) - 1 {
@@ -250,11 +389,25 @@ func (self *FunctionLiteral) String() string {
     return out.String()
 }
 
+// An argument passed by name, like n in f(1, n: 2)
+type NamedArgument struct {
+    Name *Identifier
+    Value Expression
+    Position token.Position
+}
+func (self *NamedArgument) expression() {}
+func (self *NamedArgument) Pos() token.Position { return self.Position }
+func (self *NamedArgument) String() string {
+    return self.Name.Name + ": " + self.Value.String()
+}
+
 type CallExpression struct {
     Function Expression
     Arguments []Expression
+    Position token.Position
 }
 func (self *CallExpression) expression() {}
+func (self *CallExpression) Pos() token.Position { return self.Position }
 func (self *CallExpression) String() string {
     var out bytes.Buffer
 
@@ -275,14 +428,19 @@ type DotExpression struct {
     Left Expression
     Method Expression
     Arguments []Expression
+    Position token.Position
 }
 func (self *DotExpression) expression() {}
+func (self *DotExpression) Pos() token.Position { return self.Position }
 func (self *DotExpression) String() string {
     var out bytes.Buffer
 
     out.WriteString(self.Left.String())
     out.WriteString(".")
     out.WriteString(self.Method.String())
+    if self.Arguments == nil {
+        return out.String()
+    }
     out.WriteString("(")
     for i, argument := range self.Arguments {
         out.WriteString(argument.String())
@@ -300,32 +458,62 @@ func (self *DotExpression) String() string {
 // ========
 type IntegerLiteral struct {
     Value int64
+    Position token.Position
 }
 func (self *IntegerLiteral) expression() {}
+func (self *IntegerLiteral) Pos() token.Position { return self.Position }
 func (self *IntegerLiteral) String() string {
     return strconv.FormatInt(self.Value, 10)
 }
 
 type FloatLiteral struct {
     Value float64
+    Position token.Position
 }
 func (self *FloatLiteral) expression() {}
+func (self *FloatLiteral) Pos() token.Position { return self.Position }
 func (self *FloatLiteral) String() string {
     return strconv.FormatFloat(self.Value, 'f', -1, 64)
 }
 
 type StringLiteral struct {
     Value string
+    Position token.Position
 }
 func (self *StringLiteral) expression() {}
+func (self *StringLiteral) Pos() token.Position { return self.Position }
 func (self *StringLiteral) String() string {
     return self.Value
 }
 
+type InterpolatedString struct {
+    Parts []Expression
+    Position token.Position
+}
+func (self *InterpolatedString) expression() {}
+func (self *InterpolatedString) Pos() token.Position { return self.Position }
+func (self *InterpolatedString) String() string {
+    var out bytes.Buffer
+
+    for _, part := range self.Parts {
+        if literal, ok := part.(*StringLiteral); ok {
+            out.WriteString(literal.Value)
+            continue
+        }
+        out.WriteString("{")
+        out.WriteString(part.String())
+        out.WriteString("}")
+    }
+
+    return out.String()
+}
+
 type BooleanLiteral struct {
     Value bool
+    Position token.Position
 }
 func (self *BooleanLiteral) expression() {}
+func (self *BooleanLiteral) Pos() token.Position { return self.Position }
 func (self *BooleanLiteral) String() string {
     return strconv.FormatBool(self.Value)
 }
@@ -335,8 +523,10 @@ func (self *BooleanLiteral) String() string {
 // ======
 type ListLiteral struct {
     Elements []Expression
+    Position token.Position
 }
 func (self *ListLiteral) expression() {}
+func (self *ListLiteral) Pos() token.Position { return self.Position }
 func (self *ListLiteral) String() string {
     var out bytes.Buffer
 
@@ -351,22 +541,94 @@ func (self *ListLiteral) String() string {
 
     return out.String()
 }
+type TupleLiteral struct {
+    Elements []Expression
+    Position token.Position
+}
+func (self *TupleLiteral) expression() {}
+func (self *TupleLiteral) Pos() token.Position { return self.Position }
+func (self *TupleLiteral) String() string {
+    var out bytes.Buffer
+
+    out.WriteString("tuple(")
+    for i, element := range self.Elements {
+        out.WriteString(element.String())
+        if i < len(self.Elements) - 1 {
+            out.WriteString(", ")
+        }
+    }
+    out.WriteString(")")
+
+    return out.String()
+}
+
+type VecLiteral struct {
+    Elements []Expression
+    Position token.Position
+}
+func (self *VecLiteral) expression() {}
+func (self *VecLiteral) Pos() token.Position { return self.Position }
+func (self *VecLiteral) String() string {
+    var out bytes.Buffer
+
+    out.WriteString("vec(")
+    for i, element := range self.Elements {
+        out.WriteString(element.String())
+        if i < len(self.Elements) - 1 {
+            out.WriteString(", ")
+        }
+    }
+    out.WriteString(")")
+
+    return out.String()
+}
 
 // ===========
 // COLLECTIONS
 // ===========
+type SetLiteral struct {
+    Elements []Expression
+    Position token.Position
+}
+func (self *SetLiteral) expression() {}
+func (self *SetLiteral) Pos() token.Position { return self.Position }
+func (self *SetLiteral) String() string {
+    var out bytes.Buffer
+
+    out.WriteString("set(")
+    for i, element := range self.Elements {
+        out.WriteString(element.String())
+        if i < len(self.Elements) - 1 {
+            out.WriteString(", ")
+        }
+    }
+    out.WriteString(")")
+
+    return out.String()
+}
+
+// The pairs are kept in source order so that keys are evaluated in order
 type MapLiteral struct {
-    Pairs map[Expression]Expression
+    Pairs []*MapPair
+    Position token.Position
+}
+type MapPair struct {
+    Key Expression
+    Value Expression
 }
 func (self *MapLiteral) expression() {}
+func (self *MapLiteral) Pos() token.Position { return self.Position }
 func (self *MapLiteral) String() string {
     var out bytes.Buffer
 
     out.WriteString("map(")
-    for key, value := range self.Pairs {
-        out.WriteString(key.String())
+    for i, pair := range self.Pairs {
+        out.WriteString(pair.Key.String())
         out.WriteString(": ")
-        out.WriteString(value.String())
+        out.WriteString(pair.Value.String())
+        if i < len(self.Pairs) - 1 {
+            out.WriteString(", ")
+        }
     }
     out.WriteString(")")
 
@@ -375,8 +637,12 @@ func (self *MapLiteral) String() string {
 
 type StructLiteral struct {
     Fields []*Identifier
+    Interfaces []*Identifier
+    Methods []*LetStatement
+    Position token.Position
 }
 func (self *StructLiteral) expression() {}
+func (self *StructLiteral) Pos() token.Position { return self.Position }
 func (self *StructLiteral) String() string {
     var out bytes.Buffer
 
@@ -388,6 +654,91 @@ func (self *StructLiteral) String() string {
         }
     }
     out.WriteString(")")
+    if len(self.Interfaces) > 0 {
+        out.WriteString(" is ")
+        for i, name := range self.Interfaces {
+            out.WriteString(name.Name)
+            if i < len(self.Interfaces) - 1 {
+                out.WriteString(", ")
+            }
+        }
+    }
+    if len(self.Methods) > 0 {
+        out.WriteString(" { ")
+        for _, method := range self.Methods {
+            out.WriteString(method.String())
+        }
+        out.WriteString(" }")
+    }
+
+    return out.String()
+}
+
+// A set of method signatures, like interface(area: fn(): f64)
+type InterfaceLiteral struct {
+    Methods []*Identifier
+    Position token.Position
+}
+func (self *InterfaceLiteral) expression() {}
+func (self *InterfaceLiteral) Pos() token.Position { return self.Position }
+func (self *InterfaceLiteral) String() string {
+    var out bytes.Buffer
+
+    out.WriteString("interface(")
+    for i, method := range self.Methods {
+        out.WriteString(method.Name + ": " + method.Type.String())
+        if i < len(self.Methods) - 1 {
+            out.WriteString(", ")
+        }
+    }
+    out.WriteString(")")
+
+    return out.String()
+}
+
+type EnumLiteral struct {
+    Variants []*EnumVariant
+    Position token.Position
+}
+func (self *EnumLiteral) expression() {}
+func (self *EnumLiteral) Pos() token.Position { return self.Position }
+func (self *EnumLiteral) String() string {
+    var out bytes.Buffer
+
+    out.WriteString("enum(")
+    for i, variant := range self.Variants {
+        out.WriteString(variant.String())
+        if i < len(self.Variants) - 1 {
+            out.WriteString(", ")
+        }
+    }
+    out.WriteString(")")
+
+    return out.String()
+}
+
+// A variant of an enum, with the fields of its payload if it has one
+type EnumVariant struct {
+    Name string
+    Fields []*Identifier
+    Position token.Position
+}
+func (self *EnumVariant) String() string {
+    var out bytes.Buffer
+
+    out.WriteString(self.Name)
+    if len(self.Fields) > 0 {
+        out.WriteString("(")
+        for i, field := range self.Fields {
+            out.WriteString(field.String())
+            out.WriteString(": ")
+            out.WriteString(field.Type.String())
+            if i < len(self.Fields) - 1 {
+                out.WriteString(", ")
+            }
+        }
+        out.WriteString(")")
+    }
 
     return out.String()
 }
@@ -399,8 +750,10 @@ func (self *StructLiteral) String() string {
 type WhileExpression struct {
     Condition Expression
     Body *BlockStatement
+    Position token.Position
 }
 func (self *WhileExpression) expression() {}
+func (self *WhileExpression) Pos() token.Position { return self.Position }
 func (self *WhileExpression) String() string {
     var out bytes.Buffer
 
@@ -417,8 +770,10 @@ type ForExpression struct {
     Value *Identifier
     Iterable Expression
     Body *BlockStatement
+    Position token.Position
 }
 func (self *ForExpression) expression() {}
+func (self *ForExpression) Pos() token.Position { return self.Position }
 func (self *ForExpression) String() string {
     var out bytes.Buffer
 
@@ -436,8 +791,10 @@ func (self *ForExpression) String() string {
 
 type BreakStatement struct {
     Condition Expression
+    Position token.Position
 }
 func (self *BreakStatement) statement() {}
+func (self *BreakStatement) Pos() token.Position { return self.Position }
 func (self *BreakStatement) String() string {
     var out bytes.Buffer
 
@@ -452,8 +809,10 @@ func (self *BreakStatement) String() string {
 
 type ContinueStatement struct {
     Condition Expression
+    Position token.Position
 }
 func (self *ContinueStatement) statement() {}
+func (self *ContinueStatement) Pos() token.Position { return self.Position }
 func (self *ContinueStatement) String() string {
     var out bytes.Buffer
 
@@ -21,6 +21,15 @@ func AsF64(args ...object.Object) object.Object {
             }
             return f
         }()}
+    case *object.Vec:
+        if arg.Subtype == object.F64_OBJ {
+            return arg
+        }
+        vec := &object.Vec{Subtype: object.F64_OBJ, Floats: make([]float64, len(arg.Ints))}
+        for i, value := range arg.Ints {
+            vec.Floats[i] = float64(value)
+        }
+        return vec
     default:
         return object.NewError("as_f64() cannot convert %s to f64", object.TypeName[arg.Type()])
     }
@@ -0,0 +1,28 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+func AsList(args ...object.Object) object.Object {
+    if len(args) != 1 {
+        return object.NewError("as_list() takes exactly one argument")
+    }
+
+    switch arg := args[0].(type) {
+    case *object.List:
+        return arg.Copy()
+    case *object.Vec:
+        return &object.List{Elements: arg.Elements()}
+    case *object.Tuple:
+        return &object.List{Elements: append([]object.Object{}, arg.Elements...)}
+    case *object.Set:
+        elements := []object.Object{}
+        for _, key := range arg.Keys {
+            elements = append(elements, arg.Elements[key])
+        }
+        return &object.List{Elements: elements}
+    default:
+        return object.NewError("as_list() cannot convert %s to list", object.TypeName[arg.Type()])
+    }
+}
@@ -0,0 +1,24 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+func AsVec(args ...object.Object) object.Object {
+    if len(args) != 1 {
+        return object.NewError("as_vec() takes exactly one argument")
+    }
+
+    switch arg := args[0].(type) {
+    case *object.Vec:
+        return arg
+    case *object.List:
+        vec, ok := object.NewVec(arg.Elements)
+        if !ok {
+            return object.NewError("vec elements must be all i64 or all f64")
+        }
+        return vec
+    default:
+        return object.NewError("as_vec() cannot convert %s to vec", object.TypeName[arg.Type()])
+    }
+}
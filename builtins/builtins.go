@@ -7,24 +7,46 @@ import (
 var Builtins = map[string]*object.BuiltIn{
     "print": { Function: Print },
     "printf": { Function: PrintF },
-    "len": { Function: Len },
-    "input": { Function: Input },
-    "type": { Function: Type },
+    "len": { Function: Len, Signature: `fn[T](value: T): i64` },
+    "input": { Function: Input, Signature: `fn(prompt: str = ""): str` },
+    "type": { Function: Type, Signature: `fn[T](value: T): str` },
     "read": { Function: Read },
-    "as_i64": { Function: AsI64 },
-    "as_f64": { Function: AsF64 },
-    "as_str": { Function: AsStr },
-    "split": { Function: Split },
-    "join": { Function: Join },
-    "append": { Function: Append },
+    "as_i64": { Function: AsI64, Signature: `fn[T](value: T): i64` },
+    "as_f64": { Function: AsF64, Signature: `fn[T](value: T): f64` },
+    "as_str": { Function: AsStr, Signature: `fn[T](value: T): str` },
+    "split": { Function: Split, Signature: `fn(s: str, separator: str): list(str)` },
+    "join": { Function: Join, Signature: `fn[T](xs: list(T), separator: str): str` },
+    "append": { Function: Append, Signature: `fn[T](xs: list(T), x: T): list(T)` },
     "sum": { Function: Sum },
     "max": { Function: Max },
     "min": { Function: Min },
-    "sort": { Function: Sort },
-    "reverse": { Function: Reverse },
-    "concat": { Function: Concat },
+    "sort": { Function: Sort, Signature: `fn[T](xs: list(T)): list(T)` },
+    "reverse": { Function: Reverse, Signature: `fn[T](xs: list(T)): list(T)` },
+    "concat": { Function: Concat, Signature: `fn[T](xs: list(T), ys: list(T)): list(T)` },
     "with_size": { Function: WithSize },
     "transpose": { Function: Transpose },
     "sqrt": { Function: Sqrt },
-    "strip": { Function: Strip },
+    "strip": { Function: Strip, Signature: `fn(s: str): str` },
+    "bytes": { Function: Bytes },
+    "union": { Function: Union, Signature: `fn[T](a: set(T), b: set(T)): set(T)` },
+    "intersection": { Function: Intersection, Signature: `fn[T](a: set(T), b: set(T)): set(T)` },
+    "difference": { Function: Difference, Signature: `fn[T](a: set(T), b: set(T)): set(T)` },
+    "is_subset": { Function: IsSubset, Signature: `fn[T](a: set(T), b: set(T)): bool` },
+    "is_superset": { Function: IsSuperset, Signature: `fn[T](a: set(T), b: set(T)): bool` },
+    "has": { Function: Has },
+    "dot": { Function: Dot },
+    "norm": { Function: Norm },
+    "as_list": { Function: AsList },
+    "as_vec": { Function: AsVec },
+    "keys": { Function: Keys, Signature: `fn[K, V](m: map(K, V)): list(K)` },
+    "values": { Function: Values, Signature: `fn[K, V](m: map(K, V)): list(V)` },
+    "items": { Function: Items, Signature: `fn[K, V](m: map(K, V)): list(tuple(K, V))` },
+    "remove": { Function: Remove, Signature: `fn[K, V](m: map(K, V), key: K): map(K, V)` },
+    "get": { Function: Get, Signature: `fn[K, V](m: map(K, V), key: K, default: V): V` },
+    "merge": { Function: Merge, Signature: `fn[K, V](m: map(K, V), other: map(K, V)): map(K, V)` },
+}
+
+func nativeBool(value bool) *object.Bool {
+    if value { return object.TRUE }
+    return object.FALSE
 }
@@ -0,0 +1,22 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+func Bytes(args ...object.Object) object.Object {
+    if len(args) != 1 {
+        return object.NewError("bytes() takes exactly one argument")
+    }
+    if args[0].Type() != object.STR_OBJ {
+        return object.NewError("bytes() takes a string argument")
+    }
+
+    value := args[0].(*object.Str).Value
+    elements := make([]object.Object, len(value))
+    for i := 0; i < len(value); i++ {
+        elements[i] = &object.I64{Value: int64(value[i])}
+    }
+
+    return &object.List{Elements: elements}
+}
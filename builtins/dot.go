@@ -0,0 +1,40 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+func Dot(args ...object.Object) object.Object {
+    if len(args) != 2 {
+        return object.NewError("dot() takes exactly two arguments")
+    }
+    left, ok := args[0].(*object.Vec)
+    if !ok {
+        return object.NewError("argument to `dot` must be VEC, got %s", object.TypeName[args[0].Type()])
+    }
+    right, ok := args[1].(*object.Vec)
+    if !ok {
+        return object.NewError("argument to `dot` must be VEC, got %s", object.TypeName[args[1].Type()])
+    }
+    if left.Subtype != right.Subtype {
+        return object.NewError("dot() takes vecs of the same type, got %s and %s",
+            object.TypeName[left.Subtype], object.TypeName[right.Subtype])
+    }
+    if left.Len() != right.Len() {
+        return object.NewError("vec lengths differ: %d and %d", left.Len(), right.Len())
+    }
+
+    if left.Subtype == object.F64_OBJ {
+        var total float64
+        for i, value := range left.Floats {
+            total += value * right.Floats[i]
+        }
+        return &object.F64{Value: total}
+    }
+
+    var total int64
+    for i, value := range left.Ints {
+        total += value * right.Ints[i]
+    }
+    return &object.I64{Value: total}
+}
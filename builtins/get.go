@@ -0,0 +1,16 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+// Returns the default only when the key is missing, not when it holds none
+func Get(args ...object.Object) object.Object {
+    mapObject, err := mapArgument("get", 3, args)
+    if err != nil { return err }
+
+    if value, ok := mapObject.Get(args[1]); ok {
+        return value
+    }
+    return args[2]
+}
@@ -0,0 +1,20 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+func Has(args ...object.Object) object.Object {
+    if len(args) != 2 {
+        return object.NewError("has() takes exactly two arguments")
+    }
+    switch arg := args[0].(type) {
+    case *object.Set:
+        return nativeBool(arg.Has(args[1]))
+    case *object.Map:
+        _, ok := arg.Get(args[1])
+        return nativeBool(ok)
+    default:
+        return object.NewError("argument to `has` must be SET or MAP, got %s", object.TypeName[args[0].Type()])
+    }
+}
@@ -0,0 +1,18 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+func Intersection(args ...object.Object) object.Object {
+    left, right, err := setArguments("intersection", args)
+    if err != nil { return err }
+
+    set := object.NewSet()
+    for _, key := range left.Keys {
+        if _, ok := right.Elements[key]; ok {
+            set.Add(left.Elements[key])
+        }
+    }
+    return set
+}
@@ -0,0 +1,21 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+func IsSubset(args ...object.Object) object.Object {
+    left, right, err := setArguments("is_subset", args)
+    if err != nil { return err }
+
+    return nativeBool(isSubset(left, right))
+}
+
+func isSubset(left *object.Set, right *object.Set) bool {
+    for _, key := range left.Keys {
+        if _, ok := right.Elements[key]; !ok {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,12 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+func IsSuperset(args ...object.Object) object.Object {
+    left, right, err := setArguments("is_superset", args)
+    if err != nil { return err }
+
+    return nativeBool(isSubset(right, left))
+}
@@ -0,0 +1,18 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+// Returns the pairs as a list of (key, value) tuples
+func Items(args ...object.Object) object.Object {
+    mapObject, err := mapArgument("items", 1, args)
+    if err != nil { return err }
+
+    elements := []object.Object{}
+    for _, key := range mapObject.Keys {
+        pair := mapObject.Pairs[key]
+        elements = append(elements, &object.Tuple{Elements: []object.Object{pair.Key, pair.Value}})
+    }
+    return &object.List{Elements: elements}
+}
@@ -0,0 +1,28 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+func Keys(args ...object.Object) object.Object {
+    mapObject, err := mapArgument("keys", 1, args)
+    if err != nil { return err }
+
+    elements := []object.Object{}
+    for _, key := range mapObject.Keys {
+        elements = append(elements, mapObject.Pairs[key].Key)
+    }
+    return &object.List{Elements: elements}
+}
+
+// Checks that a map operation was called with a map and n arguments in total
+func mapArgument(name string, n int, args []object.Object) (*object.Map, *object.Error) {
+    if len(args) != n {
+        return nil, object.NewError("wrong number of arguments. got=%d, want=%d", len(args), n)
+    }
+    mapObject, ok := args[0].(*object.Map)
+    if !ok {
+        return nil, object.NewError("argument to `%s` must be MAP, got %s", name, object.TypeName[args[0].Type()])
+    }
+    return mapObject, nil
+}
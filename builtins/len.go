@@ -1,6 +1,9 @@
 package builtins
 
-import "kimchi/object"
+import (
+    "unicode/utf8"
+    "kimchi/object"
+)
 
 func Len(args ...object.Object) object.Object {
     if len(args) != 1 {
@@ -8,10 +11,18 @@ func Len(args ...object.Object) object.Object {
     }
     switch arg := args[0].(type) {
     case *object.Str:
-        return &object.I64{Value: int64(len(arg.Value))}
+        return &object.I64{Value: int64(utf8.RuneCountInString(arg.Value))}
     case *object.List:
         return &object.I64{Value: int64(len(arg.Elements))}
+    case *object.Tuple:
+        return &object.I64{Value: int64(len(arg.Elements))}
+    case *object.Set:
+        return &object.I64{Value: int64(len(arg.Keys))}
+    case *object.Vec:
+        return &object.I64{Value: int64(arg.Len())}
+    case *object.Map:
+        return &object.I64{Value: int64(len(arg.Keys))}
     default:
-        return object.NewError("len() takes a string or list argument")
+        return object.NewError("len() takes a string, list, tuple, set, vec or map argument")
     }
 }
@@ -12,10 +12,13 @@ func Max(args ...object.Object) object.Object {
 
     if args[0].Type() != object.LIST_OBJ {
         return object.NewError("argument to `max` must be a list, got %s",
-            args[0].Type())
+            object.TypeName[args[0].Type()])
     }
 
     elements := args[0].(*object.List).Elements
+    if len(elements) == 0 {
+        return object.NewError("empty list passed to `max`")
+    }
 
     switch elements[0].Type() {
     case object.I64_OBJ:
@@ -35,6 +38,6 @@ func Max(args ...object.Object) object.Object {
         }
         return maxElement
     default:
-        return object.NewError("argument to `max` must be a list of integers or floats, got %s", elements[0].Type())
+        return object.NewError("argument to `max` must be a list of integers or floats, got %s", object.TypeName[elements[0].Type()])
     }
 }
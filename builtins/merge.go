@@ -0,0 +1,23 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+// Values of the second map win over the first one
+func Merge(args ...object.Object) object.Object {
+    left, err := mapArgument("merge", 2, args)
+    if err != nil { return err }
+
+    right, ok := args[1].(*object.Map)
+    if !ok {
+        return object.NewError("argument to `merge` must be MAP, got %s", object.TypeName[args[1].Type()])
+    }
+
+    result := left.Copy()
+    for _, key := range right.Keys {
+        pair := right.Pairs[key]
+        result.Set(pair.Key, pair.Value)
+    }
+    return result
+}
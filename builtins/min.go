@@ -11,6 +11,9 @@ func Min(args ...object.Object) object.Object {
     if args[0].Type() != object.LIST_OBJ {
         return object.NewError("argument to `min` must be a list, got %s", object.TypeName[args[0].Type()])
     }
+    if len(args[0].(*object.List).Elements) == 0 {
+        return object.NewError("empty list passed to `min`")
+    }
     if args[0].(*object.List).Elements[0].Type() != object.I64_OBJ && args[0].(*object.List).Elements[0].Type() != object.F64_OBJ {
         return object.NewError("elements of list must be integers, got %s", object.TypeName[args[0].(*object.List).Elements[0].Type()])
     }
@@ -0,0 +1,26 @@
+package builtins
+
+import (
+    "math"
+    "kimchi/object"
+)
+
+// Euclidean norm of a vec
+func Norm(args ...object.Object) object.Object {
+    if len(args) != 1 {
+        return object.NewError("norm() takes exactly one argument")
+    }
+    vec, ok := args[0].(*object.Vec)
+    if !ok {
+        return object.NewError("argument to `norm` must be VEC, got %s", object.TypeName[args[0].Type()])
+    }
+
+    var total float64
+    for _, value := range vec.Floats {
+        total += value * value
+    }
+    for _, value := range vec.Ints {
+        total += float64(value * value)
+    }
+    return &object.F64{Value: math.Sqrt(total)}
+}
@@ -0,0 +1,14 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+func Remove(args ...object.Object) object.Object {
+    mapObject, err := mapArgument("remove", 2, args)
+    if err != nil { return err }
+
+    result := mapObject.Copy()
+    result.Delete(args[1])
+    return result
+}
@@ -12,13 +12,32 @@ func Sort(args ...object.Object) object.Object {
     if args[0].Type() != object.LIST_OBJ {
         return object.NewError("argument to `sort` must be a list, got %s", object.TypeName[args[0].Type()])
     }
-    if args[0].(*object.List).Elements[0].Type() != object.I64_OBJ && args[0].(*object.List).Elements[0].Type() != object.F64_OBJ {
-        return object.NewError("elements of list must be integers, got %s", object.TypeName[args[0].(*object.List).Elements[0].Type()])
+
+    // Sorted on a copy, the list passed in keeps its order
+    elements := args[0].(*object.List).Copy().Elements
+    if len(elements) == 0 {
+        return &object.List{Elements: elements}
+    }
+
+    kind := elements[0].Type()
+    if kind != object.I64_OBJ && kind != object.F64_OBJ && kind != object.STR_OBJ {
+        return object.NewError("elements of list must be integers, floats or strings, got %s", object.TypeName[kind])
     }
-    
-    elements := args[0].(*object.List).Elements
-    sort.Slice(elements, func(i, j int) bool {
-        return elements[i].(*object.I64).Value < elements[j].(*object.I64).Value
+    for _, element := range elements {
+        if element.Type() != kind {
+            return object.NewError("elements of list must all be %s, got %s", object.TypeName[kind], object.TypeName[element.Type()])
+        }
+    }
+
+    sort.SliceStable(elements, func(i, j int) bool {
+        switch kind {
+        case object.I64_OBJ:
+            return elements[i].(*object.I64).Value < elements[j].(*object.I64).Value
+        case object.F64_OBJ:
+            return elements[i].(*object.F64).Value < elements[j].(*object.F64).Value
+        default:
+            return elements[i].(*object.Str).Value < elements[j].(*object.Str).Value
+        }
     })
 
     return &object.List{Elements: elements}
@@ -22,11 +22,6 @@ func Split(args ...object.Object) object.Object {
     strValue := args[0].(*object.Str).Value
     sepValue := args[1].(*object.Str).Value
 
-    // TODO: This is a hack. Need to figure out how to do this properly.
-    if strings.Contains(sepValue, "\\n") {
-        sepValue = strings.Replace(sepValue, "\\n", "\n", -1)
-    }
-
     elements := strings.Split(strValue, sepValue)
 
     result := &object.List{Elements: make([]object.Object, len(elements))}
@@ -8,5 +8,11 @@ func Type(args ...object.Object) object.Object {
     if len(args) != 1 {
         return object.NewError("type() takes exactly one argument")
     }
+    if instance, ok := args[0].(*object.Struct); ok && instance.Definition.Name != "" {
+        return &object.Str{Value: instance.Definition.Name}
+    }
+    if value, ok := args[0].(*object.EnumValue); ok && value.Enum.Name != "" {
+        return &object.Str{Value: value.Enum.Name}
+    }
     return &object.Str{Value: object.TypeName[args[0].Type()]}
 }
@@ -0,0 +1,32 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+func Union(args ...object.Object) object.Object {
+    left, right, err := setArguments("union", args)
+    if err != nil { return err }
+
+    set := left.Copy()
+    for _, key := range right.Keys {
+        set.Add(right.Elements[key])
+    }
+    return set
+}
+
+// Checks that a set operation was called with two sets
+func setArguments(name string, args []object.Object) (*object.Set, *object.Set, *object.Error) {
+    if len(args) != 2 {
+        return nil, nil, object.NewError("%s() takes exactly two arguments", name)
+    }
+    left, ok := args[0].(*object.Set)
+    if !ok {
+        return nil, nil, object.NewError("argument to `%s` must be SET, got %s", name, object.TypeName[args[0].Type()])
+    }
+    right, ok := args[1].(*object.Set)
+    if !ok {
+        return nil, nil, object.NewError("argument to `%s` must be SET, got %s", name, object.TypeName[args[1].Type()])
+    }
+    return left, right, nil
+}
@@ -0,0 +1,16 @@
+package builtins
+
+import (
+    "kimchi/object"
+)
+
+func Values(args ...object.Object) object.Object {
+    mapObject, err := mapArgument("values", 1, args)
+    if err != nil { return err }
+
+    elements := []object.Object{}
+    for _, key := range mapObject.Keys {
+        elements = append(elements, mapObject.Pairs[key].Value)
+    }
+    return &object.List{Elements: elements}
+}
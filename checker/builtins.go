@@ -0,0 +1,56 @@
+package checker
+
+import (
+    "fmt"
+    "kimchi/ast"
+    "kimchi/builtins"
+    "kimchi/parser"
+    "kimchi/std"
+    "kimchi/tokenizer"
+)
+
+// The types of the builtins and of the prelude, shared by every checker as
+// its outer scopes, so that programs can shadow them. Like when the
+// program runs, builtins are found before the prelude.
+var builtinScope = builtinTypes(preludeTypes())
+
+// Builtins without a signature are set to nil, so that they still hide
+// the names of the prelude
+func builtinTypes(outer *Scope) *Scope {
+    scope := NewScope(outer)
+    for name, builtin := range builtins.Builtins {
+        if builtin.Signature == "" {
+            scope.Set(name, nil)
+            continue
+        }
+        scope.Set(name, signatureType(name, builtin.Signature))
+    }
+    return scope
+}
+// The names declared by the prelude. A prelude that doesn't parse is left
+// out, and reported when the program uses it.
+func preludeTypes() *Scope {
+    parser := parser.New(tokenizer.NewFile(std.PRELUDE, std.Prelude()))
+    program := parser.ParseProgram()
+    if len(parser.Errors) > 0 { return NewScope(nil) }
+
+    checker := &Checker{scope: NewScope(builtinTypes(nil))}
+    checker.Check(program)
+
+    return &Scope{names: checker.scope.names}
+}
+// Signatures are written like function literals without body
+func signatureType(name, signature string) *Type {
+    parser := parser.New(tokenizer.New(signature + " {}"))
+    program := parser.ParseProgram()
+    if len(parser.Errors) > 0 || len(program.Statements) != 1 {
+        panic(fmt.Sprintf("invalid signature of %s: %s", name, signature))
+    }
+
+    statement, ok := program.Statements[0].(*ast.ExpressionStatement)
+    if !ok { panic(fmt.Sprintf("invalid signature of %s: %s", name, signature)) }
+    literal, ok := statement.Expression.(*ast.FunctionLiteral)
+    if !ok { panic(fmt.Sprintf("invalid signature of %s: %s", name, signature)) }
+
+    return functionType(literal)
+}
@@ -0,0 +1,735 @@
+package checker
+
+import (
+    "fmt"
+    "strings"
+    "kimchi/ast"
+    "kimchi/token"
+)
+
+// Checks the type annotations of a program before it runs. Types that can't
+// be inferred, like the results of builtins without a signature, are left
+// unchecked, so that only code that would certainly break an annotation is
+// reported.
+type Checker struct {
+    Errors []*CheckError
+    scope *Scope
+    // Return type of the function being checked, nil at the top level
+    returnType *Type
+    // Checks the module of a use statement, so that its errors are
+    // reported before the program runs. Modules are skipped when nil.
+    Modules func(statement *ast.UseStatement) error
+}
+
+type Scope struct {
+    names map[string]*Type
+    outer *Scope
+}
+func NewScope(outer *Scope) *Scope {
+    return &Scope{names: make(map[string]*Type), outer: outer}
+}
+func (self *Scope) Get(name string) *Type {
+    if result, ok := self.names[name]; ok {
+        return result
+    }
+    if self.outer != nil {
+        return self.outer.Get(name)
+    }
+    return nil
+}
+func (self *Scope) Set(name string, value *Type) {
+    self.names[name] = value
+}
+
+// An argument of a call, with its type already inferred
+type argument struct {
+    Name string
+    Type *Type
+    Position token.Position
+}
+
+func New() *Checker {
+    return &Checker{scope: NewScope(builtinScope)}
+}
+
+// ==============
+// PUBLIC METHODS
+// ==============
+// The names declared by the program are kept, so that a REPL can check
+// one line at a time
+func (self *Checker) Check(program *ast.Program) []*CheckError {
+    self.Errors = []*CheckError{}
+    for _, statement := range program.Statements {
+        self.checkStatement(statement)
+    }
+    return self.Errors
+}
+
+// ======
+// ERRORS
+// ======
+func (self *Checker) addError(code string, position token.Position, format string, a ...interface{}) {
+    self.Errors = append(self.Errors, &CheckError{Code: code, Message: fmt.Sprintf(format, a...), Position: position})
+}
+
+// ==========
+// STATEMENTS
+// ==========
+func (self *Checker) checkStatement(statement ast.Statement) {
+    switch statement := statement.(type) {
+    case *ast.LetStatement:
+        self.checkLetStatement(statement)
+    case *ast.MutStatement:
+        self.checkMutStatement(statement)
+    case *ast.ExeStatement:
+        self.checkExeStatement(statement)
+    case *ast.ReturnStatement:
+        result := self.typeOf(statement.Expression)
+        if self.returnType != nil && !self.assignable(self.returnType, result) {
+            self.addError(TYPE_MISMATCH, start(statement.Expression), "expected return type %s, got %s", self.returnType, result)
+        }
+    case *ast.ExpressionStatement:
+        self.typeOf(statement.Expression)
+    case *ast.BlockStatement:
+        self.checkBlock(statement)
+    case *ast.BreakStatement:
+        self.typeOf(statement.Condition)
+    case *ast.ContinueStatement:
+        self.typeOf(statement.Condition)
+    case *ast.UseStatement:
+        if self.Modules != nil {
+            if err := self.Modules(statement); err != nil {
+                self.addError(INVALID_MODULE, statement.Position, "%s", err.Error())
+            }
+        }
+        self.scope.Set(statement.Name, &Type{Kind: token.USE, Name: statement.Name})
+    }
+}
+func (self *Checker) checkBlock(block *ast.BlockStatement) {
+    if block == nil { return }
+    for _, statement := range block.Statements {
+        self.checkStatement(statement)
+    }
+}
+func (self *Checker) checkLetStatement(statement *ast.LetStatement) {
+    if statement.Identifier == nil {
+        value := self.typeOf(statement.Expression)
+        for i, target := range statement.Targets {
+            if target.Name == "_" { continue }
+            var element *Type
+            if value.is(token.TUPLE) && len(value.Elements) == len(statement.Targets) {
+                element = value.Elements[i]
+            }
+            self.scope.Set(target.Name, element)
+        }
+        return
+    }
+
+    name := statement.Identifier.Name
+    declared := fromLiteral(statement.Identifier.Type)
+
+    var value *Type
+    switch literal := statement.Expression.(type) {
+    case *ast.FunctionLiteral:
+        // Bound before the body is checked, for recursive functions
+        value = functionType(literal)
+        self.scope.Set(name, value)
+        self.checkFunction(literal, value, nil)
+    case *ast.StructLiteral:
+        value = self.structType(literal, name)
+    case *ast.EnumLiteral:
+        value = &Type{Kind: token.ENUM, Name: name, Variants: literal.Variants}
+    case *ast.InterfaceLiteral:
+        value = self.interfaceType(literal, name)
+    default:
+        value = self.typeOf(statement.Expression)
+    }
+
+    if !self.assignable(declared, value) {
+        self.addError(TYPE_MISMATCH, start(statement.Expression), "cannot assign %s to %s of type %s", value, name, declared)
+    }
+
+    // Inferred types are more precise than partial annotations, like fn
+    if declared == nil || (declared.isPartial() && value != nil) {
+        declared = value
+    }
+    self.scope.Set(name, declared)
+}
+func (self *Checker) checkMutStatement(statement *ast.MutStatement) {
+    if targets, ok := statement.Identifier.(*ast.TupleLiteral); ok {
+        value := self.typeOf(statement.Expression)
+        for i, target := range targets.Elements {
+            var element *Type
+            if value.is(token.TUPLE) && len(value.Elements) == len(targets.Elements) {
+                element = value.Elements[i]
+            }
+            self.checkAssignment(target, self.typeOf(target), element, start(statement.Expression))
+        }
+        return
+    }
+
+    target := self.typeOf(statement.Identifier)
+
+    // The shorthand, like mut x to + 1, reuses the target as left operand
+    var value *Type
+    if infix, ok := statement.Expression.(*ast.InfixExpression); ok && infix.Left == statement.Identifier {
+        value = self.infixType(infix.Operator, target, self.typeOf(infix.Right))
+    } else {
+        value = self.typeOf(statement.Expression)
+    }
+    self.checkAssignment(statement.Identifier, target, value, start(statement.Expression))
+}
+func (self *Checker) checkAssignment(target ast.Expression, declared, value *Type, position token.Position) {
+    if !self.assignable(declared, value) {
+        self.addError(TYPE_MISMATCH, position, "cannot assign %s to %s of type %s", value, target, declared)
+    }
+}
+// exe discards the result, so it is only used on functions returning none
+func (self *Checker) checkExeStatement(statement *ast.ExeStatement) {
+    var result *Type
+    name := statement.Function.String()
+    if method, ok := statement.Function.(*ast.DotExpression); ok {
+        result = self.typeOf(method)
+        name = method.Left.String() + "." + method.Method.String()
+    } else {
+        function := self.typeOf(statement.Function)
+        result = self.callType(statement.Function.String(), function, self.arguments(statement.Arguments), statement.Position)
+    }
+
+    if result != nil && !result.is(token.NONE) {
+        self.addError(INVALID_EXE, statement.Position, "exe needs a function returning none, %s returns %s", name, result)
+    }
+}
+
+// ============
+// DECLARATIONS
+// ============
+// Checks the body of a function, with receiver bound to self for methods
+func (self *Checker) checkFunction(literal *ast.FunctionLiteral, signature, receiver *Type) {
+    outerScope, outerReturn := self.scope, self.returnType
+    self.scope, self.returnType = NewScope(outerScope), signature.Return
+    defer func() { self.scope, self.returnType = outerScope, outerReturn }()
+
+    // Type parameters are opaque in the body, and only match themselves
+    for _, name := range signature.TypeParameters {
+        self.scope.Set(name, namedType(name))
+    }
+    for i, parameter := range literal.Parameters {
+        parameterType := signature.Elements[i]
+        if parameter.Default != nil {
+            value := self.typeOf(parameter.Default)
+            if !self.assignable(parameterType, value) {
+                self.addError(TYPE_MISMATCH, start(parameter.Default), "cannot assign %s to %s of type %s", value, parameter.Name, parameterType)
+            }
+        }
+        // Variadic parameters collect their arguments in a list
+        if parameter.Variadic {
+            parameterType = newType(token.LIST, parameterType)
+        }
+        self.scope.Set(parameter.Name, parameterType)
+    }
+    if receiver != nil {
+        self.scope.Set("self", receiver)
+    }
+
+    self.checkBlock(literal.Body)
+}
+func (self *Checker) structType(literal *ast.StructLiteral, name string) *Type {
+    result := &Type{Kind: token.STRUCT, Name: name, Fields: literal.Fields, Methods: map[string]*Type{}}
+    for _, identifier := range literal.Interfaces {
+        result.Interfaces = append(result.Interfaces, identifier.Name)
+    }
+    if name != "" {
+        self.scope.Set(name, result)
+    }
+
+    // Signatures first, so that methods can call each other
+    functions := map[string]*ast.FunctionLiteral{}
+    for _, method := range literal.Methods {
+        if function, ok := method.Expression.(*ast.FunctionLiteral); ok {
+            functions[method.Identifier.Name] = function
+            result.Methods[method.Identifier.Name] = functionType(function)
+        }
+    }
+    for _, method := range literal.Methods {
+        if function, ok := functions[method.Identifier.Name]; ok {
+            self.checkFunction(function, result.Methods[method.Identifier.Name], namedType(name))
+        }
+    }
+
+    return result
+}
+func (self *Checker) interfaceType(literal *ast.InterfaceLiteral, name string) *Type {
+    result := &Type{Kind: token.INTERFACE, Name: name, Methods: map[string]*Type{}}
+    for _, method := range literal.Methods {
+        result.Methods[method.Name] = fromLiteral(method.Type)
+    }
+    return result
+}
+
+// ===========
+// EXPRESSIONS
+// ===========
+func (self *Checker) typeOf(expression ast.Expression) *Type {
+    switch node := expression.(type) {
+    case *ast.IntegerLiteral:
+        return newType(token.I64)
+    case *ast.FloatLiteral:
+        return newType(token.F64)
+    case *ast.StringLiteral:
+        return newType(token.STR)
+    case *ast.InterpolatedString:
+        for _, part := range node.Parts {
+            self.typeOf(part)
+        }
+        return newType(token.STR)
+    case *ast.BooleanLiteral:
+        return newType(token.BOOL)
+    case *ast.Identifier:
+        return self.scope.Get(node.Name)
+
+    case *ast.ListLiteral:
+        return self.collectionType(token.LIST, "list elements", node.Elements)
+    case *ast.SetLiteral:
+        return self.collectionType(token.SET, "set elements", node.Elements)
+    case *ast.VecLiteral:
+        return self.collectionType(token.VEC, "vec elements", node.Elements)
+    case *ast.TupleLiteral:
+        result := newType(token.TUPLE)
+        for _, element := range node.Elements {
+            result.Elements = append(result.Elements, self.typeOf(element))
+        }
+        return result
+    case *ast.MapLiteral:
+        return self.mapType(node)
+
+    case *ast.FunctionLiteral:
+        result := functionType(node)
+        self.checkFunction(node, result, nil)
+        return result
+    case *ast.StructLiteral:
+        return self.structType(node, "")
+    case *ast.EnumLiteral:
+        return &Type{Kind: token.ENUM, Variants: node.Variants}
+    case *ast.InterfaceLiteral:
+        return self.interfaceType(node, "")
+
+    case *ast.PrefixExpression:
+        right := self.typeOf(node.Right)
+        if node.Operator == "not" {
+            return newType(token.BOOL)
+        }
+        return right
+    case *ast.InfixExpression:
+        return self.infixType(node.Operator, self.typeOf(node.Left), self.typeOf(node.Right))
+    case *ast.CallExpression:
+        function := self.typeOf(node.Function)
+        return self.callType(node.Function.String(), function, self.arguments(node.Arguments), node.Position)
+    case *ast.DotExpression:
+        return self.dotType(node)
+    case *ast.NamedArgument:
+        return self.typeOf(node.Value)
+
+    case *ast.IfExpression:
+        self.typeOf(node.Condition)
+        self.checkBlock(node.Consequence)
+        self.checkBlock(node.Alternative)
+        return nil
+    case *ast.MatchExpression:
+        self.checkExhaustive(node, self.typeOf(node.Subject))
+        for _, arm := range node.Arms {
+            self.checkArm(arm)
+        }
+        return nil
+    case *ast.WhileExpression:
+        self.typeOf(node.Condition)
+        self.checkBlock(node.Body)
+        return nil
+    case *ast.ForExpression:
+        self.checkForExpression(node)
+        return nil
+    }
+    return nil
+}
+// The elements of a collection literal must all have the same type
+func (self *Checker) collectionType(kind int, description string, elements []ast.Expression) *Type {
+    element := self.commonType(description, elements)
+    if element == nil {
+        return newType(kind)
+    }
+    return newType(kind, element)
+}
+func (self *Checker) commonType(description string, elements []ast.Expression) *Type {
+    var result *Type
+    for _, element := range elements {
+        elementType := self.typeOf(element)
+        if result == nil {
+            result = elementType
+        } else if !self.assignable(result, elementType) {
+            self.addError(TYPE_MISMATCH, start(element), "%s must all be %s, got %s", description, result, elementType)
+        }
+    }
+    return result
+}
+func (self *Checker) mapType(node *ast.MapLiteral) *Type {
+    keys, values := []ast.Expression{}, []ast.Expression{}
+    for _, pair := range node.Pairs {
+        keys = append(keys, pair.Key)
+        values = append(values, pair.Value)
+    }
+    key := self.commonType("map keys", keys)
+    value := self.commonType("map values", values)
+    if key == nil && value == nil {
+        return newType(token.MAP)
+    }
+    return newType(token.MAP, key, value)
+}
+func (self *Checker) infixType(operator string, left, right *Type) *Type {
+    switch operator {
+    case "and", "or", "<", ">", "<=", ">=", "is", "is_not", "in":
+        return newType(token.BOOL)
+    }
+    if left == nil || right == nil { return nil }
+
+    // Vectors broadcast numbers
+    if left.is(token.VEC) && (right.is(token.I64) || right.is(token.F64) || right.is(token.VEC)) {
+        return left
+    }
+    if left.Kind != right.Kind { return nil }
+
+    switch left.Kind {
+    case token.I64, token.F64:
+        return left
+    case token.STR, token.LIST:
+        if operator == "+" && self.assignable(left, right) {
+            return left
+        }
+    }
+    return nil
+}
+func (self *Checker) arguments(expressions []ast.Expression) []argument {
+    result := []argument{}
+    for _, expression := range expressions {
+        if named, ok := expression.(*ast.NamedArgument); ok {
+            result = append(result, argument{Name: named.Name.Name, Type: self.typeOf(named.Value), Position: named.Name.Position})
+            continue
+        }
+        result = append(result, argument{Type: self.typeOf(expression), Position: start(expression)})
+    }
+    return result
+}
+// The result of calling a value, which can also index a collection
+func (self *Checker) callType(name string, function *Type, args []argument, position token.Position) *Type {
+    if function == nil { return nil }
+
+    switch function.Kind {
+    case token.FN:
+        if function.Return == nil { return nil }
+        bindings := function.bindings()
+        self.checkArguments(name, function.Parameters, function.Elements, args, position, bindings)
+        return substitute(function.Return, bindings)
+    case token.STRUCT:
+        self.checkArguments(name, function.Fields, fieldTypes(function.Fields), args, position, nil)
+        return namedType(function.Name)
+    case token.LIST, token.VEC:
+        if len(args) == 1 && args[0].Type.is(token.I64) {
+            return function.element()
+        }
+    case token.STR:
+        return newType(token.STR)
+    case token.MAP:
+        if len(args) != 1 || len(function.Elements) != 2 { return nil }
+        if !self.assignable(function.Elements[0], args[0].Type) {
+            self.addError(TYPE_MISMATCH, args[0].Position, "map key must be %s, got %s", function.Elements[0], args[0].Type)
+        }
+        return function.Elements[1]
+    }
+    return nil
+}
+// Parameters are only known for functions declared with a literal. Without
+// them, only the types of positional arguments are checked. The type
+// parameters of generic functions are bound from the arguments, in order.
+func (self *Checker) checkArguments(name string, parameters []*ast.Identifier, types []*Type, args []argument, position token.Position, bindings map[string]*Type) {
+    given := map[int]bool{}
+    variadic := len(parameters) > 0 && parameters[len(parameters) - 1].Variadic
+
+    for i, arg := range args {
+        index := i
+        if arg.Name != "" {
+            if parameters == nil { continue }
+            index = parameterIndex(parameters, arg.Name)
+            if index < 0 {
+                self.addError(INVALID_ARGUMENTS, arg.Position, "%s has no parameter %s", name, arg.Name)
+                continue
+            }
+        } else if index >= len(types) {
+            if variadic {
+                index = len(types) - 1
+            } else {
+                if parameters != nil {
+                    self.addError(INVALID_ARGUMENTS, arg.Position, "%s takes at most %d arguments, got %d", name, len(types), len(args))
+                }
+                return
+            }
+        }
+        given[index] = true
+
+        expected := types[index]
+        if bindings != nil {
+            unify(expected, arg.Type, bindings)
+            expected = substitute(expected, bindings)
+        }
+        if !self.assignable(expected, arg.Type) {
+            self.addError(TYPE_MISMATCH, arg.Position, "argument %s of %s must be %s, got %s", parameterName(parameters, index), name, expected, arg.Type)
+        }
+    }
+
+    for i, parameter := range parameters {
+        if !given[i] && parameter.Default == nil && !parameter.Variadic {
+            self.addError(INVALID_ARGUMENTS, position, "missing argument %s of %s", parameter.Name, name)
+        }
+    }
+}
+func (self *Checker) dotType(node *ast.DotExpression) *Type {
+    left := self.typeOf(node.Left)
+    args := self.arguments(node.Arguments)
+    name := node.Method.(*ast.Identifier).Name
+    call := node.Left.String() + "." + name
+
+    // The members of modules and of unknown values aren't inferred
+    if left == nil || left.is(token.USE) { return nil }
+
+    switch left.Kind {
+    case token.ENUM:
+        return self.variantType(left, name, call, node, args)
+    case token.IDENTIFIER:
+        definition := self.scope.Get(left.Name)
+        if definition == nil { return nil }
+        if definition.is(token.STRUCT) {
+            for i, field := range definition.Fields {
+                if field.Name != name { continue }
+                if node.Arguments == nil {
+                    return fieldTypes(definition.Fields)[i]
+                }
+                return self.callType(call, fromLiteral(field.Type), args, node.Position)
+            }
+        }
+        if definition.is(token.STRUCT) || definition.is(token.INTERFACE) {
+            if method, ok := definition.Methods[name]; ok {
+                return self.callType(call, method, args, node.Position)
+            }
+        }
+    }
+
+    // Functions called with dot syntax take the receiver first
+    function := self.scope.Get(name)
+    if !function.is(token.FN) || function.Return == nil { return nil }
+
+    receiver := argument{Type: left, Position: start(node.Left)}
+    return self.callType(name, function, append([]argument{receiver}, args...), node.Position)
+}
+func (self *Checker) variantType(enum *Type, name, call string, node *ast.DotExpression, args []argument) *Type {
+    for _, variant := range enum.Variants {
+        if variant.Name != name { continue }
+
+        value := namedType(enum.Name)
+        if len(variant.Fields) == 0 {
+            return value
+        }
+        // Variants with a payload are constructors until they are called
+        constructor := &Type{Kind: token.FN, Elements: fieldTypes(variant.Fields), Return: value, Parameters: variant.Fields}
+        if node.Arguments == nil {
+            return constructor
+        }
+        return self.callType(call, constructor, args, node.Position)
+    }
+    return nil
+}
+func (self *Checker) checkForExpression(node *ast.ForExpression) {
+    iterable := self.typeOf(node.Iterable)
+
+    var index, value *Type
+    if iterable != nil {
+        index = newType(token.I64)
+        switch iterable.Kind {
+        case token.LIST, token.SET, token.VEC:
+            value = iterable.element()
+        case token.STR:
+            value = newType(token.STR)
+        case token.MAP:
+            if len(iterable.Elements) == 2 {
+                index, value = iterable.Elements[0], iterable.Elements[1]
+            } else {
+                index = nil
+            }
+        }
+    }
+
+    // Loop variables only live in the body
+    outerScope := self.scope
+    self.scope = NewScope(outerScope)
+    defer func() { self.scope = outerScope }()
+
+    if node.Index.Name != "_" {
+        self.scope.Set(node.Index.Name, index)
+    }
+    if node.Value.Name != "_" {
+        self.scope.Set(node.Value.Name, value)
+    }
+    self.checkBlock(node.Body)
+}
+// Matches on enum values must cover every variant with an arm without a
+// guard. Arms that only bind the payload of a variant cover it, and an arm
+// that is a single binding or _ covers every variant.
+func (self *Checker) checkExhaustive(node *ast.MatchExpression, subject *Type) {
+    if !subject.is(token.IDENTIFIER) { return }
+    enum := self.scope.Get(subject.Name)
+    if !enum.is(token.ENUM) { return }
+
+    covered := map[string]bool{}
+    for _, arm := range node.Arms {
+        if arm.Guard != nil { continue }
+
+        switch pattern := arm.Pattern.(type) {
+        case *ast.Identifier:
+            return
+        case *ast.DotExpression:
+            if self.typeOf(pattern.Left) != enum || !bindsOnly(pattern.Arguments) { continue }
+            covered[pattern.Method.(*ast.Identifier).Name] = true
+        }
+    }
+
+    missing := []string{}
+    for _, variant := range enum.Variants {
+        if !covered[variant.Name] {
+            missing = append(missing, enum.Name + "." + variant.Name)
+        }
+    }
+    if len(missing) > 0 {
+        self.addError(NON_EXHAUSTIVE, node.Position, "non-exhaustive match: %s not covered", strings.Join(missing, ", "))
+    }
+}
+// The names bound by the pattern of an arm only live in the arm
+func (self *Checker) checkArm(arm *ast.MatchArm) {
+    outerScope := self.scope
+    self.scope = NewScope(outerScope)
+    defer func() { self.scope = outerScope }()
+
+    self.bindPattern(arm.Pattern)
+    self.typeOf(arm.Guard)
+    self.checkBlock(arm.Body)
+}
+// The names bound by a match pattern are not inferred
+func (self *Checker) bindPattern(pattern ast.Expression) {
+    switch pattern := pattern.(type) {
+    case *ast.Identifier:
+        if pattern.Name != "_" {
+            self.scope.Set(pattern.Name, nil)
+        }
+    case *ast.RestPattern:
+        self.bindPattern(pattern.Identifier)
+    case *ast.CallExpression:
+        for _, argument := range pattern.Arguments {
+            self.bindPattern(argument)
+        }
+    case *ast.DotExpression:
+        for _, argument := range pattern.Arguments {
+            self.bindPattern(argument)
+        }
+    case *ast.ListLiteral:
+        for _, element := range pattern.Elements {
+            self.bindPattern(element)
+        }
+    case *ast.TupleLiteral:
+        for _, element := range pattern.Elements {
+            self.bindPattern(element)
+        }
+    }
+}
+
+// ===========
+// ASSIGNMENTS
+// ===========
+func (self *Checker) assignable(target, value *Type) bool {
+    if target == nil || value == nil { return true }
+
+    if target.Kind == token.IDENTIFIER {
+        if value.Kind == token.IDENTIFIER && value.Name == target.Name { return true }
+
+        definition := self.scope.Get(target.Name)
+        if definition == nil { return true }
+        // Structs can be used as the interfaces they implement
+        if definition.is(token.INTERFACE) && value.Kind == token.IDENTIFIER {
+            implementation := self.scope.Get(value.Name)
+            if implementation == nil { return true }
+            for _, name := range implementation.Interfaces {
+                if name == target.Name { return true }
+            }
+        }
+        return false
+    }
+    if target.Kind != value.Kind { return false }
+
+    switch target.Kind {
+    case token.FN:
+        if target.Return == nil || value.Return == nil { return true }
+        if len(target.Elements) != len(value.Elements) { return false }
+        for i := range target.Elements {
+            if !self.assignable(value.Elements[i], target.Elements[i]) { return false }
+        }
+        return self.assignable(target.Return, value.Return)
+    case token.LIST, token.SET, token.VEC, token.MAP, token.TUPLE:
+        if len(target.Elements) == 0 || len(value.Elements) == 0 { return true }
+        if len(target.Elements) != len(value.Elements) { return false }
+        for i := range target.Elements {
+            if !self.assignable(target.Elements[i], value.Elements[i]) { return false }
+        }
+    }
+    return true
+}
+
+// =======
+// HELPERS
+// =======
+// Calls, dots and operators are positioned at their operator, but errors
+// point to where the expression starts
+func start(expression ast.Expression) token.Position {
+    switch expression := expression.(type) {
+    case *ast.CallExpression:
+        return start(expression.Function)
+    case *ast.DotExpression:
+        return start(expression.Left)
+    case *ast.InfixExpression:
+        return start(expression.Left)
+    }
+    return expression.Pos()
+}
+func fieldTypes(fields []*ast.Identifier) []*Type {
+    result := []*Type{}
+    for _, field := range fields {
+        result = append(result, fromLiteral(field.Type))
+    }
+    return result
+}
+func bindsOnly(patterns []ast.Expression) bool {
+    for _, pattern := range patterns {
+        switch pattern.(type) {
+        case *ast.Identifier, *ast.RestPattern:
+        default:
+            return false
+        }
+    }
+    return true
+}
+func parameterIndex(parameters []*ast.Identifier, name string) int {
+    for i, parameter := range parameters {
+        if parameter.Name == name { return i }
+    }
+    return -1
+}
+func parameterName(parameters []*ast.Identifier, index int) string {
+    if index < len(parameters) {
+        return parameters[index].Name
+    }
+    return fmt.Sprint(index + 1)
+}
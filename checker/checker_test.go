@@ -0,0 +1,175 @@
+package checker
+
+import (
+    "testing"
+    "kimchi/ast"
+    "kimchi/tokenizer"
+    "kimchi/parser"
+)
+
+func TestValidPrograms(t *testing.T) {
+    tests := []string{
+        `let x: i64 = 5 mut x to x + 1 mut x to + 1`,
+        `let x be 5 let y: f64 = 2.5 let s: str = "a{x}"`,
+        `let xs: list(i64) = list(1, 2, 3) mut xs(0) to 4`,
+        `let xs: list = list(1, 2) let ys: list(str) = list()`,
+        `let m: map(str, i64) = map("a": 1) mut m("b") to 2 let v: i64 = m("a")`,
+        `let f be fn(x: i64, y: i64 = 2): i64 { return x + y } let a: i64 = f(1) let b: i64 = f(1, y: 3)`,
+        `let total be fn(xs: ...i64): i64 { return 0 } let a: i64 = total() let b: i64 = total(1, 2, 3)`,
+        `let fact be fn(n: i64): i64 { return n * fact(n - 1) }`,
+        `let greet be fn(name: str): none { print(name) } exe greet("ana") exe print("hi")`,
+        `let twice be fn(f: fn(i64): i64, x: i64): i64 { return f(f(x)) } twice(fn(x: i64): i64 { return x }, 1)`,
+        `let Point be struct(x: i64, y: i64) let p: Point = Point(1, 2) let x: i64 = p.x mut p.x to 3`,
+        `let Point be struct(x: i64, y: i64) let p: Point = Point(y: 2, x: 1)`,
+        `let Point be struct(x: i64) {
+            let move be fn(dx: i64): none { mut self.x to self.x + dx }
+            let get be fn(): i64 { return self.x }
+        }
+        let p: Point = Point(1)
+        exe p.move(2)
+        let x: i64 = p.get()`,
+        `let Shape be interface(area: fn(): f64)
+        let Square be struct(side: f64) is Shape { let area be fn(): f64 { return self.side * self.side } }
+        let s: Shape = Square(2.0)`,
+        `let Color be enum(Red, Rgb(r: i64, g: i64, b: i64)) let c: Color = Color.Rgb(1, 2, 3) let d: Color = Color.Red`,
+        `let divmod be fn(a: i64, b: i64): tuple(i64, i64) { return a / b, a - a / b * b } let q, r be divmod(7, 2) let x: i64 = q`,
+        `let double be fn(x: i64): i64 { return x * 2 } let y: i64 = 3.double()`,
+        `for k, v in map("a": 1) { let key: str = k let value: i64 = v }`,
+        `for i, x in list(1.0, 2.0) { let index: i64 = i let value: f64 = x }`,
+        `let Color be enum(Red, Green) let c: Color = Color.Red match c { Color.Red: 1, Color.Green: 2 }`,
+        `let Color be enum(Red, Green) let c: Color = Color.Red match c { Color.Red: 1, other: 2 }`,
+        `let Shape be enum(Circle(r: f64), Empty) match Shape.Empty { Shape.Circle(r): 1, _: 0 }`,
+        `use "math" let y: f64 = math.pow(2.0, 3)`,
+        `let xs: list(i64) = sort(list(3, 1))`,
+        `let a be 1 let b be a + 2 let c: i64 = b`,
+        `let xs be list(1, 2) let first: i64 = xs(0) let m be map("a": 1.0) let v: f64 = m("a")`,
+        `let f be fn(x: i64): str { return "a" } let s be f(1) let t: str = s`,
+        `let x be (5) let y: i64 = x let n be -5 let m: i64 = n`,
+        `let xs be (list(1, 2)) let ys: list(i64) = xs let k be len(xs) let j: i64 = k`,
+        `let first be fn[T](xs: list(T)): T { return xs(0) } let a: i64 = first(list(1, 2)) let b: str = first(list("a"))`,
+        `let pair be fn[A, B](a: A, b: B): tuple(A, B) { return tuple(a, b) } let p: tuple(i64, str) = pair(1, "a")`,
+        `let apply be fn[T, U](x: T, f: fn(T): U): U { return f(x) } let s: str = apply(1, fn(x: i64): str { return "a" })`,
+        `let first be fn[T](xs: list(T)): T { return xs(0) } let head be fn[T](xs: list(T)): T { return first(xs) }`,
+        `let first be fn[T](xs: list(T)): T { return xs(0) } let x: i64 = list(1).first()`,
+        `let m: i64 = max(list(1, 2)) let r: f64 = sqrt(4) let xs: list(str) = sort(list("b", "a")) let n: i64 = len("abc")`,
+        `let ks: list(str) = keys(map("a": 1)) let v: i64 = get(map("a": 1), "b", 0)`,
+        `let len be fn(x: str): str { return x } let s: str = len("a")`,
+        `let f: list(i64) = flatten(list(list(1), list(2))) let z: list(tuple(i64, str)) = zip(list(1), list("a"))`,
+    }
+
+    for _, input := range tests {
+        errors := testCheck(t, input)
+        for _, err := range errors {
+            t.Errorf("unexpected error for %q: %s", input, err.Error())
+        }
+    }
+}
+
+func TestTypeErrors(t *testing.T) {
+    tests := []struct {
+        input string
+        expected []string
+    }{
+        { `let x: i64 = "hi"`, []string{"1:14: cannot assign str to x of type i64"}, },
+        { `let x: f64 = 1`, []string{"1:14: cannot assign i64 to x of type f64"}, },
+        { `let x be 1 mut x to "a"`, []string{"1:21: cannot assign str to x of type i64"}, },
+        { `let xs: list(i64) = list(1, 2) mut xs(0) to "a"`, []string{"1:45: cannot assign str to xs(0) of type i64"}, },
+        { `let xs: list(str) = list(1, 2)`, []string{"1:21: cannot assign list(i64) to xs of type list(str)"}, },
+        { `list(1, "a", 2.0)`, []string{"1:9: list elements must all be i64, got str", "1:14: list elements must all be i64, got f64"}, },
+        { `set(1, "a")`, []string{"1:8: set elements must all be i64, got str"}, },
+        { `map("a": 1, 2: "b")`, []string{"1:13: map keys must all be str, got i64", "1:16: map values must all be i64, got str"}, },
+        { `let m: map(str, i64) = map("a": 1) mut m("b") to "c"`, []string{"1:50: cannot assign str to m(b) of type i64"}, },
+        { `let m: map(str, i64) = map("a": 1) m(1)`, []string{"1:38: map key must be str, got i64"}, },
+        { `let f be fn(x: i64): i64 { return x } f("a")`, []string{"1:41: argument x of f must be i64, got str"}, },
+        { `let f be fn(x: i64): i64 { return x } f()`, []string{"1:40: missing argument x of f"}, },
+        { `let f be fn(x: i64): i64 { return x } f(1, 2)`, []string{"1:44: f takes at most 1 arguments, got 2"}, },
+        { `let f be fn(x: i64, y: i64 = 1): i64 { return x } f(1, z: 2)`, []string{"1:56: f has no parameter z"}, },
+        { `let f be fn(x: i64, y: i64 = 1): i64 { return x } f(1, y: "a")`, []string{"1:56: argument y of f must be i64, got str"}, },
+        { `let f be fn(x: i64 = "a"): i64 { return x }`, []string{"1:22: cannot assign str to x of type i64"}, },
+        { `let f be fn(xs: ...i64): i64 { return 0 } f(1, "a")`, []string{"1:48: argument xs of f must be i64, got str"}, },
+        { `let f be fn(x: i64): str { return x }`, []string{"1:35: expected return type str, got i64"}, },
+        { `let f be fn(x: i64): i64 { if x > 0 { return "a" } return x }`, []string{"1:46: expected return type i64, got str"}, },
+        { `let f be fn(x: i64): i64 { return x } exe f(1)`, []string{"1:39: exe needs a function returning none, f returns i64"}, },
+        { `let apply be fn(f: fn(i64): i64): i64 { return f(1) } apply(fn(x: str): i64 { return 1 })`, []string{"1:61: argument f of apply must be fn(i64): i64, got fn(str): i64"}, },
+        { `let Point be struct(x: i64, y: i64) Point(1, "a")`, []string{"1:46: argument y of Point must be i64, got str"}, },
+        { `let Point be struct(x: i64, y: i64) Point(y: "a", x: 1)`, []string{"1:43: argument y of Point must be i64, got str"}, },
+        { `let Point be struct(x: i64) let p: Point = Point(1) mut p.x to "a"`, []string{"1:64: cannot assign str to p.x of type i64"}, },
+        { `let Point be struct(x: i64) { let get be fn(): i64 { return "a" } }`, []string{"1:61: expected return type i64, got str"}, },
+        { `let Point be struct(x: i64) { let get be fn(): i64 { return self.x } } let p: Point = Point(1) exe p.get()`, []string{"1:96: exe needs a function returning none, p.get returns i64"}, },
+        { `let Point be struct(x: i64) let Line be struct(a: Point) let p: Point = Line(Point(1))`, []string{"1:73: cannot assign Line to p of type Point"}, },
+        { `let Shape be interface(area: fn(): f64) let Square be struct(side: f64) let s: Shape = Square(1.0)`, []string{"1:88: cannot assign Square to s of type Shape"}, },
+        { `let Color be enum(Red, Rgb(r: i64, g: i64, b: i64)) Color.Rgb(1, 2, "a")`, []string{"1:69: argument b of Color.Rgb must be i64, got str"}, },
+        { `let double be fn(x: i64): i64 { return x * 2 } "a".double()`, []string{"1:48: argument x of double must be i64, got str"}, },
+        { `for k, v in map("a": 1) { let x: i64 = k }`, []string{"1:40: cannot assign str to x of type i64"}, },
+        { `let Color be enum(Red, Green, Blue) let c: Color = Color.Red match c { Color.Red: 1 }`, []string{"1:62: non-exhaustive match: Color.Green, Color.Blue not covered"}, },
+        { `let Shape be enum(Circle(r: f64), Empty) match Shape.Empty { Shape.Circle(r) if r > 1.0: 1, Shape.Empty: 0 }`, []string{"1:42: non-exhaustive match: Shape.Circle not covered"}, },
+        { `let x: i64 = 5 match 3 { x: 1 } mut x to "oops"`, []string{"1:42: cannot assign str to x of type i64"}, },
+        { `let x: i64 = 5 for _, x in list("a") { } mut x to "hello"`, []string{"1:51: cannot assign str to x of type i64"}, },
+        { `let a be 1 let b be a * 2 mut b to "a"`, []string{"1:36: cannot assign str to b of type i64"}, },
+        { `let xs be list(1, 2) mut xs to list("a")`, []string{"1:32: cannot assign list(str) to xs of type list(i64)"}, },
+        { `let f be fn(x: i64): str { return "a" } let s be f(1) let n: i64 = s`, []string{"1:68: cannot assign str to n of type i64"}, },
+        { `let first be fn[T](xs: list(T)): T { return xs(0) } let s: str = first(list(1))`, []string{"1:66: cannot assign i64 to s of type str"}, },
+        { `let pick be fn[T](a: T, b: T): T { return a } pick(1, "a")`, []string{"1:55: argument b of pick must be i64, got str"}, },
+        { `let id be fn[T](x: T): T { return 1 }`, []string{"1:35: expected return type T, got i64"}, },
+        { `let xs: list(i64) = reverse(list("a"))`, []string{"1:21: cannot assign list(str) to xs of type list(i64)"}, },
+        { `sort(list(1), list(2))`, []string{"1:15: sort takes at most 1 arguments, got 2"}, },
+        { `concat(list(1), list("a"))`, []string{"1:17: argument ys of concat must be list(i64), got list(str)"}, },
+        { `let s: str = zip(list(1), list(2))`, []string{"1:14: cannot assign list(tuple(i64, i64)) to s of type str"}, },
+        { `chunk(list(1), "y")`, []string{"1:16: argument size of chunk must be i64, got str"}, },
+        { `get(map("a": 1), 1, 0)`, []string{"1:18: argument key of get must be str, got i64"}, },
+    }
+
+    for _, tt := range tests {
+        errors := testCheck(t, tt.input)
+        if len(errors) != len(tt.expected) {
+            t.Errorf("wrong number of errors for %q. expected=%d, got=%d (%v)", tt.input, len(tt.expected), len(errors), errors)
+            continue
+        }
+        for i, err := range errors {
+            if err.Error() != tt.expected[i] {
+                t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected[i], err.Error())
+            }
+        }
+    }
+}
+
+func TestRenderCheckError(t *testing.T) {
+    input := "let x: i64 = 1\nlet y: str = x"
+    errors := testCheck(t, input)
+    if len(errors) != 1 {
+        t.Fatalf("expected 1 error, got %d", len(errors))
+    }
+
+    expected := "error[C001]: cannot assign i64 to y of type str\n" +
+        " --> 2:14\n" +
+        "  |\n" +
+        "2 | let y: str = x\n" +
+        "  |              ^\n"
+    if errors[0].Render(input) != expected {
+        t.Errorf("wrong rendering. expected=\n%s\ngot=\n%s", expected, errors[0].Render(input))
+    }
+}
+
+func TestCheckerKeepsDeclarations(t *testing.T) {
+    checker := New()
+    checker.Check(parse(t, `let x: i64 = 1`))
+    errors := checker.Check(parse(t, `mut x to "a"`))
+    if len(errors) != 1 {
+        t.Fatalf("expected 1 error, got %d", len(errors))
+    }
+}
+
+// =======
+// HELPERS
+// =======
+func testCheck(t *testing.T, input string) []*CheckError {
+    return New().Check(parse(t, input))
+}
+func parse(t *testing.T, input string) *ast.Program {
+    parser := parser.New(tokenizer.New(input))
+    program := parser.ParseProgram()
+    if len(parser.Errors) != 0 {
+        t.Fatalf("parser has %d errors for %q: %s", len(parser.Errors), input, parser.Errors[0].Error())
+    }
+    return program
+}
@@ -0,0 +1,39 @@
+package checker
+
+import (
+    "kimchi/parser"
+    "kimchi/token"
+)
+
+// Error codes
+const (
+    TYPE_MISMATCH = "C001"
+    INVALID_ARGUMENTS = "C002"
+    INVALID_EXE = "C003"
+    NON_EXHAUSTIVE = "C004"
+    INVALID_MODULE = "C005"
+)
+
+type CheckError struct {
+    Code string
+    Message string
+    Position token.Position
+}
+func (self *CheckError) Error() string {
+    if self.Position.IsValid() {
+        return self.Position.String() + ": " + self.Message
+    }
+    return self.Message
+}
+
+// Renders the error followed by the offending source line, with a caret
+// under the start of the expression:
+//
+//     error[C001]: cannot assign str to x of type i64
+//      --> main.kimchi:1:14
+//       |
+//     1 | let x: i64 = "hi"
+//       |              ^
+func (self *CheckError) Render(source string) string {
+    return parser.RenderError(self.Code, self.Message, self.Position, source, 1)
+}
@@ -0,0 +1,173 @@
+package checker
+
+import (
+    "bytes"
+    "strings"
+    "kimchi/ast"
+    "kimchi/token"
+)
+
+// A static type. Kind is the token subtype of the type, like token.I64 or
+// token.LIST, and token.IDENTIFIER for values of structs, enums and
+// interfaces, which are found by Name. A nil *Type is a type that couldn't
+// be inferred, and is compatible with every other type.
+type Type struct {
+    Kind int
+    Name string
+    // Element types of collections and tuples, and parameter types of
+    // functions
+    Elements []*Type
+    Return *Type
+    // Parameters of functions declared with a literal, used to check named
+    // arguments, defaults and variadics
+    Parameters []*ast.Identifier
+    // Names of the type parameters of generic functions
+    TypeParameters []string
+    // Declarations of structs, enums and interfaces
+    Fields []*ast.Identifier
+    Methods map[string]*Type
+    Interfaces []string
+    Variants []*ast.EnumVariant
+}
+func (self *Type) String() string {
+    if self == nil { return "?" }
+
+    switch self.Kind {
+    case token.IDENTIFIER:
+        return self.Name
+    case token.STRUCT, token.ENUM, token.INTERFACE:
+        if self.Name != "" { return self.Name }
+    }
+
+    var out bytes.Buffer
+
+    out.WriteString(kindName(self.Kind))
+    if len(self.TypeParameters) > 0 {
+        out.WriteString("[" + strings.Join(self.TypeParameters, ", ") + "]")
+    }
+    if len(self.Elements) > 0 || self.Return != nil {
+        elements := []string{}
+        for _, element := range self.Elements {
+            elements = append(elements, element.String())
+        }
+        out.WriteString("(")
+        out.WriteString(strings.Join(elements, ", "))
+        out.WriteString(")")
+    }
+    if self.Return != nil {
+        out.WriteString(": ")
+        out.WriteString(self.Return.String())
+    }
+
+    return out.String()
+}
+// Collections without element types, like list, and functions without a
+// signature, like fn, only give the kind of the value
+func (self *Type) isPartial() bool {
+    switch self.Kind {
+    case token.LIST, token.SET, token.VEC, token.MAP, token.TUPLE:
+        return len(self.Elements) == 0
+    case token.FN:
+        return self.Return == nil
+    case token.STRUCT, token.ENUM, token.INTERFACE:
+        return true
+    }
+    return false
+}
+func (self *Type) is(kind int) bool {
+    return self != nil && self.Kind == kind
+}
+// The element type of a collection with a single element type
+func (self *Type) element() *Type {
+    if self == nil || len(self.Elements) != 1 { return nil }
+    return self.Elements[0]
+}
+
+func kindName(kind int) string {
+    return strings.Trim(token.Name(kind), "'")
+}
+func newType(kind int, elements ...*Type) *Type {
+    return &Type{Kind: kind, Elements: elements}
+}
+func namedType(name string) *Type {
+    return &Type{Kind: token.IDENTIFIER, Name: name}
+}
+func fromLiteral(literal *ast.TypeLiteral) *Type {
+    if literal == nil { return nil }
+
+    result := &Type{Kind: literal.Type.Subtype}
+    if literal.Type.Subtype == token.IDENTIFIER {
+        result.Name = literal.Type.Literal
+    }
+    for _, subtype := range literal.Subtypes {
+        result.Elements = append(result.Elements, fromLiteral(subtype))
+    }
+    result.Return = fromLiteral(literal.Return)
+
+    return result
+}
+func functionType(literal *ast.FunctionLiteral) *Type {
+    result := &Type{Kind: token.FN, Parameters: literal.Parameters, Return: fromLiteral(literal.ReturnType)}
+    for _, parameter := range literal.TypeParameters {
+        result.TypeParameters = append(result.TypeParameters, parameter.Name)
+    }
+    for _, parameter := range literal.Parameters {
+        result.Elements = append(result.Elements, fromLiteral(parameter.Type))
+    }
+    return result
+}
+
+// =========
+// GENERICS
+// =========
+// The types bound to the type parameters of a generic function at a call,
+// nil for functions that aren't generic. Parameters start unbound.
+func (self *Type) bindings() map[string]*Type {
+    if len(self.TypeParameters) == 0 { return nil }
+
+    result := map[string]*Type{}
+    for _, name := range self.TypeParameters {
+        result[name] = nil
+    }
+    return result
+}
+// Binds the type parameters found in parameter to the matching parts of
+// value, like T to i64 for list(T) and list(i64). The first binding wins.
+func unify(parameter, value *Type, bindings map[string]*Type) {
+    if parameter == nil || value == nil { return }
+
+    if parameter.Kind == token.IDENTIFIER {
+        if bound, ok := bindings[parameter.Name]; ok && bound == nil {
+            bindings[parameter.Name] = value
+        }
+        return
+    }
+    if parameter.Kind != value.Kind || len(parameter.Elements) != len(value.Elements) { return }
+
+    for i := range parameter.Elements {
+        unify(parameter.Elements[i], value.Elements[i], bindings)
+    }
+    unify(parameter.Return, value.Return, bindings)
+}
+// Replaces the type parameters in t with their bindings. Unbound
+// parameters become nil, and are left unchecked.
+func substitute(t *Type, bindings map[string]*Type) *Type {
+    if t == nil || bindings == nil { return t }
+
+    if t.Kind == token.IDENTIFIER {
+        if bound, ok := bindings[t.Name]; ok {
+            return bound
+        }
+        return t
+    }
+    if len(t.Elements) == 0 && t.Return == nil { return t }
+
+    result := *t
+    result.Elements = nil
+    for _, element := range t.Elements {
+        result.Elements = append(result.Elements, substitute(element, bindings))
+    }
+    result.Return = substitute(t.Return, bindings)
+
+    return &result
+}
@@ -7,6 +7,7 @@ import (
     "strings"
     "kimchi/tokenizer"
     "kimchi/parser"
+    "kimchi/checker"
     "kimchi/evaluator"
     "kimchi/object"
 )
@@ -32,15 +33,22 @@ func main() {
     }
 
     env := object.NewEnvironment()
-    tokenizer := tokenizer.New(string(content))
+    tokenizer := tokenizer.NewFile(filename, string(content))
     parser := parser.New(tokenizer)
 
     program := parser.ParseProgram()
     if len(parser.Errors) != 0 {
-        printParserErrors(out, parser.Errors)
+        printParserErrors(out, parser.Errors, string(content))
         return 
     }
 
+    checker := checker.New()
+    checker.Modules = evaluator.CheckModule
+    if errors := checker.Check(program); len(errors) != 0 {
+        printCheckErrors(out, errors, string(content))
+        return
+    }
+
     evaluated := evaluator.Eval(program, env)
     if evaluated.Type() == object.ERROR_OBJ {
         io.WriteString(out, evaluated.Inspect())
@@ -48,9 +56,15 @@ func main() {
     }
 }
 
-func printParserErrors(out io.Writer, errors []string) {
-    io.WriteString(out, "Parser panicked! Errors: \n")
-    for _, msg := range errors {
-        io.WriteString(out, "\t"+msg+"\n")
+func printParserErrors(out io.Writer, errors []*parser.ParseError, source string) {
+    for _, err := range errors {
+        io.WriteString(out, err.Render(source))
+        io.WriteString(out, "\n")
+    }
+}
+func printCheckErrors(out io.Writer, errors []*checker.CheckError, source string) {
+    for _, err := range errors {
+        io.WriteString(out, err.Render(source))
+        io.WriteString(out, "\n")
     }
 }
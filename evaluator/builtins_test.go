@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+    "kimchi/object"
     "testing"
 )
 
@@ -22,6 +23,16 @@ func TestMax(t *testing.T) {
     testIntegerObject(t, evaluated, 3)
 }
 
+func TestMaxMinEmptyList(t *testing.T) {
+    for _, name := range []string{"max", "min"} {
+        evaluated := testEval("list()." + name + "()")
+        err, ok := evaluated.(*object.Error)
+        if !ok || err.Message != "empty list passed to `" + name + "`" {
+            t.Errorf("%s of an empty list should fail. got=%s", name, evaluated.Inspect())
+        }
+    }
+}
+
 func TestMin(t *testing.T) {
     input := `
     let x: i64 = list(1, 2, 3).min()
@@ -38,6 +49,40 @@ func TestSort(t *testing.T) {
     `
     evaluated := testEval(input)
     testIntegerListObject(t, evaluated, []int64{1, 2, 3})
+
+    input = `
+    let xs: list(i64) = list(3, 2, 1)
+    let ys: list(i64) = sort(xs)
+    xs
+    `
+    evaluated = testEval(input)
+    testIntegerListObject(t, evaluated, []int64{3, 2, 1})
+}
+
+func TestSortFloatsAndStrings(t *testing.T) {
+    tests := []struct {
+        input string
+        expected string
+    }{
+        {`list(2.5, 1.5, 3.0).sort()`, "[1.500000, 2.500000, 3.000000]"},
+        {`list("pear", "apple", "fig").sort()`, "[apple, fig, pear]"},
+        {`list().sort()`, "[]"},
+        {`list(1, "a").sort()`, "elements of list must all be i64, got str"},
+        {`list(true).sort()`, "elements of list must be integers, floats or strings, got bool"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        if err, ok := evaluated.(*object.Error); ok {
+            if err.Message != tt.expected {
+                t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, err.Message)
+            }
+            continue
+        }
+        if evaluated.Inspect() != tt.expected {
+            t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+        }
+    }
 }
 
 func TestAppend(t *testing.T) {
@@ -67,6 +112,15 @@ func TestSplit(t *testing.T) {
     testStringListObject(t, evaluated, []string{"1", "2", "3"})
 }
 
+func TestSplitEscapedSeparator(t *testing.T) {
+    input := `
+    let x: list(str) = "a\nb\nc".split("\n")
+    x
+    `
+    evaluated := testEval(input)
+    testStringListObject(t, evaluated, []string{"a", "b", "c"})
+}
+
 func TestAsStr(t *testing.T) {
     input := `
     let x: i64 = 123
@@ -117,14 +171,14 @@ func TestConcat(t *testing.T) {
 
 func TestWithSize(t *testing.T) {
     input := `
-    let x: list = list().with_size(2)
+    let x: list(i64) = list().with_size(2)
     x.len
     `
     evaluated := testEval(input)
     testIntegerObject(t, evaluated, 2)
 
     input = `
-    let x: list = list().with_size(5, 4)
+    let x: list(list(i64)) = list().with_size(5, 4)
     x(0).len
     `
     evaluated = testEval(input)
@@ -133,7 +187,7 @@ func TestWithSize(t *testing.T) {
 
 func TestTranspose(t *testing.T) {
     input := `
-    let x: list = list(list(1, 2, 3), list(4, 5, 6)).transpose()
+    let x: list(list(i64)) = list(list(1, 2, 3), list(4, 5, 6)).transpose()
     list(x.len, x(0).len)
     `
     evaluated := testEval(input)
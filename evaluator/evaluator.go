@@ -1,29 +1,40 @@
 package evaluator
 
 import (
+	"bytes"
+	"strings"
 	"kimchi/ast"
 	"kimchi/builtins"
+	"kimchi/token"
 	"kimchi/object"
 )
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
+    result := eval(node, env)
+
+    if err, ok := result.(*object.Error); ok && !err.Position.IsValid() {
+        err.Position = node.Pos()
+    }
+
+    return result
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
     switch node := node.(type) {
     case *ast.Program:
         return evalProgram(node, env)
 
     // Statements
     case *ast.LetStatement:
-        val := Eval(node.Expression, env)
-        if isError(val) { return val }
-        if val.Type() == object.LIST_OBJ {
-            return env.Set(node.Identifier.Name, val.(*object.List).Copy())
-        }
-        return env.Set(node.Identifier.Name, val)
+        return evalLetStatement(node, env)
 
     case *ast.MutStatement:
         return evalMutStatement(node, env)
 
     case *ast.ExeStatement:
+        if method, ok := node.Function.(*ast.DotExpression); ok {
+            return evalDotExpression(method, env)
+        }
         function := Eval(node.Function, env)
         if isError(function) { return function }
         
@@ -31,6 +42,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
         if len(args) == 1 && isError(args[0]) { return args[0] }
         return applyFunction(function, args)
 
+    case *ast.UseStatement:
+        return evalUseStatement(node, env)
+
     case *ast.ReturnStatement:
         val := Eval(node.Expression, env)
         if isError(val) { return val }
@@ -89,6 +103,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
     case *ast.BooleanLiteral:
         return nativeBoolToObject(node.Value)
 
+    case *ast.InterpolatedString:
+        return evalInterpolatedString(node, env)
+
     // Arrays
     case *ast.ListLiteral:
         elements := evalExpressions(node.Elements, env)
@@ -107,6 +124,14 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
         }
         return &object.List{Elements: elements}
 
+    case *ast.TupleLiteral:
+        elements := evalExpressions(node.Elements, env)
+        if len(elements) == 1 && isError(elements[0]) { return elements[0] }
+        return &object.Tuple{Elements: elements}
+
+    case *ast.VecLiteral:
+        return evalVecLiteral(node, env)
+
     // Expressions
     case *ast.PrefixExpression:
         right := Eval(node.Right, env)
@@ -123,13 +148,19 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
     case *ast.IfExpression:
         return evalIfExpression(node, env)
 
+    case *ast.MatchExpression:
+        return evalMatchExpression(node, env)
+
+    case *ast.RestPattern:
+        return object.NewError("rest patterns can only be used in match patterns")
+
     case *ast.Identifier:
         return evalIdentifier(node, env)
 
     case *ast.FunctionLiteral:
         params := node.Parameters
         body := node.Body
-        return &object.Function{Parameters: params, Body: body, Env: env}
+        return &object.Function{Parameters: params, ReturnType: node.ReturnType, Body: body, Env: env}
 
     case *ast.CallExpression:
         function := Eval(node.Function, env)
@@ -140,22 +171,28 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
         return applyFunction(function, args)
 
     case *ast.DotExpression:
-        left := Eval(node.Left, env)
-        if isError(left) { return left }
-
-        method := Eval(node.Method, env)
-        if isError(method) { return method }
+        return evalDotExpression(node, env)
 
-        args := evalExpressions(node.Arguments, env)
-        if len(args) == 1 && isError(args[0]) { return args[0] }
-        return applyMethod(left, method, args)
+    case *ast.NamedArgument:
+        value := Eval(node.Value, env)
+        if isError(value) { return value }
+        return &object.NamedArgument{Name: node.Name.Name, Value: value}
 
     // Collections
     case *ast.MapLiteral:
         return evalMapLiteral(node, env)
 
+    case *ast.SetLiteral:
+        return evalSetLiteral(node, env)
+
     case *ast.StructLiteral:
-        return evalStructLiteral(node, env)
+        return evalStructLiteral(node, env, "")
+
+    case *ast.InterfaceLiteral:
+        return evalInterfaceLiteral(node, env)
+
+    case *ast.EnumLiteral:
+        return evalEnumLiteral(node, env)
 
     // Loops
     case *ast.WhileExpression:
@@ -216,34 +253,137 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 // ==========
 // STATEMENTS
 // ==========
+func evalLetStatement(node *ast.LetStatement, env *object.Environment) object.Object {
+    var val object.Object
+    // Structs are named before their interfaces are checked, for the error messages
+    if literal, ok := node.Expression.(*ast.StructLiteral); ok && node.Identifier != nil {
+        val = evalStructLiteral(literal, env, node.Identifier.Name)
+    } else {
+        val = Eval(node.Expression, env)
+    }
+    if isError(val) { return val }
+
+    if node.Identifier != nil {
+        if definition, ok := val.(*object.StructType); ok && definition.Name == "" {
+            definition.Name = node.Identifier.Name
+        }
+        if definition, ok := val.(*object.EnumType); ok && definition.Name == "" {
+            definition.Name = node.Identifier.Name
+        }
+        if definition, ok := val.(*object.InterfaceType); ok && definition.Name == "" {
+            definition.Name = node.Identifier.Name
+        }
+        return bind(node.Identifier.Name, val, env)
+    }
+
+    values := destructure(val, len(node.Targets))
+    if len(values) == 1 && isError(values[0]) { return values[0] }
+
+    for i, target := range node.Targets {
+        if target.Name != "_" {
+            bind(target.Name, values[i], env)
+        }
+    }
+    return val
+}
+// Lists, sets and structs are copied, so that the new name doesn't share them
+func bind(name string, val object.Object, env *object.Environment) object.Object {
+    switch val := val.(type) {
+    case *object.List:
+        return env.Set(name, val.Copy())
+    case *object.Struct:
+        return env.Set(name, val.Copy())
+    case *object.Set:
+        return env.Set(name, val.Copy())
+    case *object.Map:
+        return env.Set(name, val.Copy())
+    default:
+        return env.Set(name, val)
+    }
+}
+// Splits a tuple or a list into exactly n values
+func destructure(val object.Object, n int) []object.Object {
+    var elements []object.Object
+    switch val := val.(type) {
+    case *object.Tuple:
+        elements = val.Elements
+    case *object.List:
+        elements = val.Elements
+    default:
+        return []object.Object{object.NewError("cannot destructure %s", object.TypeName[val.Type()])}
+    }
+
+    if len(elements) != n {
+        return []object.Object{object.NewError("expected %d values to destructure, got %d", n, len(elements))}
+    }
+    return elements
+}
 func evalMutStatement(node *ast.MutStatement, env *object.Environment) object.Object {
     val := Eval(node.Expression, env)
     if isError(val) { return val }
 
-    switch node.Identifier.(type) {
+    return assign(node.Identifier, val, env)
+}
+func assign(target ast.Expression, val object.Object, env *object.Environment) object.Object {
+    switch target := target.(type) {
     case *ast.Identifier:
-        return env.Set(node.Identifier.(*ast.Identifier).Name, val)
+        return env.Assign(target.Name, val)
     case *ast.CallExpression:
-        obj := Eval(node.Identifier.(*ast.CallExpression).Function, env)
+        obj := Eval(target.Function, env)
         if isError(obj) { return obj }
         
-        if obj.Type() != object.LIST_OBJ {
-            return object.NewError("expected LIST, got %s", object.TypeName[obj.Type()])
+        if obj.Type() != object.LIST_OBJ && obj.Type() != object.MAP_OBJ {
+            return object.NewError("expected LIST or MAP, got %s", object.TypeName[obj.Type()])
         }
 
-        index := Eval(node.Identifier.(*ast.CallExpression).Arguments[0], env)
+        index := Eval(target.Arguments[0], env)
         if isError(index) { return index }
 
+        if mapObject, ok := obj.(*object.Map); ok {
+            if !mapObject.Set(index, val) {
+                return object.NewError("unusable as map key: %s", object.TypeName[index.Type()])
+            }
+            return mapObject
+        }
+
         if index.Type() != object.I64_OBJ {
             return object.NewError("expected I64, got %s", object.TypeName[index.Type()])
         }
 
         list := obj.(*object.List)
-        list.Elements[index.(*object.I64).Value] = val
+        idx := index.(*object.I64).Value
+        if idx < 0 || idx > int64(len(list.Elements) - 1) {
+            return object.NewError("index out of range: %d", idx)
+        }
+        list.Elements[idx] = val
         return list
+    case *ast.DotExpression:
+        obj := Eval(target.Left, env)
+        if isError(obj) { return obj }
+
+        instance, ok := obj.(*object.Struct)
+        if !ok {
+            return object.NewError("expected struct, got %s", object.TypeName[obj.Type()])
+        }
+
+        name := target.Method.(*ast.Identifier).Name
+        if _, ok := instance.Fields[name]; !ok {
+            return object.NewError("%s has no field %s", instance.Definition.Name, name)
+        }
+        instance.Fields[name] = val
+        return instance
+    case *ast.TupleLiteral:
+        values := destructure(val, len(target.Elements))
+        if len(values) == 1 && isError(values[0]) { return values[0] }
+
+        for i, element := range target.Elements {
+            result := assign(element, values[i], env)
+            if isError(result) { return result }
+        }
+        return val
 
     default:
-        return object.NewError("expected identifier, got %s", node.Identifier.String())
+        return object.NewError("expected identifier, got %s", target.String())
     }
 }
 
@@ -261,6 +401,21 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
     }
 }
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
+    if result, ok := evalOperatorMethod(operator, left, right); ok {
+        return result
+    }
+    if left.Type() == object.STRUCT_OBJ || right.Type() == object.STRUCT_OBJ {
+        // Without eq, structs are compared field by field
+        name := operatorMethods[operator]
+        receiver := left
+        if operator == "in" { receiver = right }
+        if instance, ok := receiver.(*object.Struct); ok && name != "" && name != "eq" && instance.Definition.Methods[name] == nil {
+            return object.NewError("%s has no method %s for operator %s", instance.Definition.Name, name, operator)
+        }
+    }
+    if operator == "in" {
+        return evalInExpression(left, right)
+    }
     if left.Type() == object.I64_OBJ && right.Type() == object.I64_OBJ {
         return evalIntegerInfixExpression(operator, left, right)
     }
@@ -279,6 +434,21 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
     if left.Type() == object.LIST_OBJ && right.Type() == object.I64_OBJ {
         return evalListInfixExpression(operator, left, right)
     }
+    if left.Type() == object.ENUM_OBJ && right.Type() == object.ENUM_OBJ {
+        return evalEqualityInfixExpression(operator, left, right)
+    }
+    if left.Type() == object.STRUCT_OBJ && right.Type() == object.STRUCT_OBJ {
+        return evalEqualityInfixExpression(operator, left, right)
+    }
+    if left.Type() == object.TUPLE_OBJ && right.Type() == object.TUPLE_OBJ {
+        return evalEqualityInfixExpression(operator, left, right)
+    }
+    if left.Type() == object.SET_OBJ && right.Type() == object.SET_OBJ {
+        return evalEqualityInfixExpression(operator, left, right)
+    }
+    if left.Type() == object.VEC_OBJ || right.Type() == object.VEC_OBJ {
+        return evalVecInfixExpression(operator, left, right)
+    }
     if left.Type() != right.Type() {
         return object.NewError("cannot operate the values: %s %s %s", object.TypeName[left.Type()], operator, object.TypeName[right.Type()])
     }
@@ -302,9 +472,52 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
     if builtin, ok := builtins.Builtins[node.Name]; ok {
         return builtin
     }
+    prelude, err := preludeEnvironment()
+    if err != nil { return err }
+    if val, ok := prelude.Get(node.Name); ok {
+        return val
+    }
 
     return object.NewError("identifier not found: " + node.Name)
 }
+func evalDotExpression(node *ast.DotExpression, env *object.Environment) object.Object {
+    left := Eval(node.Left, env)
+    if isError(left) { return left }
+
+    args := evalExpressions(node.Arguments, env)
+    if len(args) == 1 && isError(args[0]) { return args[0] }
+
+    if enum, ok := left.(*object.EnumType); ok {
+        return evalEnumVariant(enum, node, args)
+    }
+    if module, ok := left.(*object.Module); ok {
+        return evalModuleMember(module, node, args)
+    }
+
+    // Struct fields
+    if instance, ok := left.(*object.Struct); ok {
+        name := node.Method.(*ast.Identifier).Name
+        if field, ok := instance.Fields[name]; ok {
+            if node.Arguments == nil {
+                return field
+            }
+            return applyFunction(field, args)
+        }
+        if method, ok := instance.Definition.Methods[name]; ok {
+            return applyStructMethod(instance, method, args)
+        }
+    }
+
+    method := Eval(node.Method, env)
+    if isError(method) {
+        if instance, ok := left.(*object.Struct); ok {
+            return object.NewError("%s has no field %s", instance.Definition.Name, node.Method.String())
+        }
+        return method
+    }
+
+    return applyMethod(left, method, args)
+}
 func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
     var result []object.Object
 
@@ -453,6 +666,40 @@ func evalListInfixExpression(operator string, left, right object.Object) object.
         return object.NewError("unknown operator: %d %s %d", left.Type(), operator, right.Type())
     }
 }
+func evalEqualityInfixExpression(operator string, left, right object.Object) object.Object {
+    switch operator {
+    case "is":
+        return nativeBoolToObject(object.Equals(left, right))
+    case "is_not":
+        return nativeBoolToObject(!object.Equals(left, right))
+    default:
+        return object.NewError("unknown operator: %s %s %s", object.TypeName[left.Type()], operator, object.TypeName[right.Type()])
+    }
+}
+
+func evalInExpression(element, container object.Object) object.Object {
+    if str, ok := container.(*object.Str); ok && element.Type() != object.STR_OBJ {
+        return object.NewError("cannot search %s in %s", object.TypeName[element.Type()], object.TypeName[str.Type()])
+    }
+
+    collection, ok := container.(object.Container)
+    if !ok {
+        return object.NewError("in operator not supported for %s", object.TypeName[container.Type()])
+    }
+    return nativeBoolToObject(collection.Contains(element))
+}
+
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+    var out bytes.Buffer
+
+    for _, part := range node.Parts {
+        value := Eval(part, env)
+        if isError(value) { return value }
+        out.WriteString(value.Inspect())
+    }
+
+    return &object.Str{Value: out.String()}
+}
 
 // ======
 // ARRAYS
@@ -463,6 +710,10 @@ func evalIndexExpression(left, index object.Object) object.Object {
         return evalListIndexExpression(left, index)
     case left.Type() == object.LIST_OBJ && index.Type() == object.SLICE_OBJ:
         return evalListSliceExpression(left, index)
+    case left.Type() == object.TUPLE_OBJ && index.Type() == object.I64_OBJ:
+        return evalTupleIndexExpression(left, index)
+    case left.Type() == object.VEC_OBJ && index.Type() == object.I64_OBJ:
+        return evalVecIndexExpression(left, index)
     case left.Type() == object.MAP_OBJ:
         return evalMapIndexExpression(left, index)
     case left.Type() == object.STR_OBJ && index.Type() == object.I64_OBJ:
@@ -496,35 +747,289 @@ func evalListSliceExpression(array, index object.Object) object.Object {
 
     return &object.List{Elements: elements}
 }
+func evalTupleIndexExpression(tuple, index object.Object) object.Object {
+    elements := tuple.(*object.Tuple).Elements
+    idx := index.(*object.I64).Value
+
+    if idx < 0 || idx >= int64(len(elements)) {
+        return object.NewError("index out of range: %d", idx)
+    }
+    return elements[idx]
+}
 func evalStringIndexExpression(str, index object.Object) object.Object {
-    strObject := str.(*object.Str)
+    runes := str.(*object.Str).Runes()
     idx := index.(*object.I64).Value
-    max := int64(len(strObject.Value) - 1)
+    max := int64(len(runes) - 1)
 
     if idx < 0 || idx > max {
         return object.NewError("index out of range: %d", idx)
     }
-    return &object.Str{Value: string(strObject.Value[idx])}
+    return &object.Str{Value: string(runes[idx])}
 }
 func evalStringSliceExpression(str, index object.Object) object.Object {
-    strObject := str.(*object.Str)
+    runes := str.(*object.Str).Runes()
     slice := index.(*object.Slice)
-    max := int(len(strObject.Value))
+    max := int(len(runes))
 
     if slice.Start < 0 || slice.End > max || slice.Start > slice.End || slice.End < 0  || slice.Start > max {
         return object.NewError("slice index out of range: %d:%d", slice.Start, slice.End)
     }
 
-    return &object.Str{Value: string(strObject.Value[slice.Start:slice.End])}
+    return &object.Str{Value: string(runes[slice.Start:slice.End])}
+}
+
+// =========
+// OPERATORS
+// =========
+// Structs support operators by defining methods with these names, like
+// let add be fn(other: Money): Money { ... }
+// All the comparisons are derived from lt and eq, and x in c calls
+// c.contains(x). Builtin types like vecs and sets implement the same
+// methods in Go.
+var operatorMethods = map[string]string{
+    "+": "add",
+    "-": "sub",
+    "*": "mul",
+    "/": "div",
+    "<": "lt",
+    ">": "lt",
+    "<=": "lt",
+    ">=": "lt",
+    "is": "eq",
+    "is_not": "eq",
+    "in": "contains",
+}
+
+// The symbols of the arithmetic operator methods of builtin types
+var arithmeticOperators = map[string]string{
+    "add": "+",
+    "sub": "-",
+    "mul": "*",
+    "div": "/",
+}
+
+// Returns false if the operator is not overloaded for the operands
+func evalOperatorMethod(operator string, left, right object.Object) (object.Object, bool) {
+    name, ok := operatorMethods[operator]
+    if !ok { return nil, false }
+
+    switch operator {
+    case "in":
+        return callOperatorMethod(name, right, left)
+    case "is_not":
+        return negate(callOperatorMethod(name, left, right))
+    case "<", ">", "<=", ">=":
+        return evalComparisonMethod(operator, left, right)
+    default:
+        return callOperatorMethod(name, left, right)
+    }
+}
+// Comparisons use lt of the left operand, or of the right one with the
+// operands swapped
+func evalComparisonMethod(operator string, left, right object.Object) (object.Object, bool) {
+    if lookupOperatorMethod(left, "lt") == nil {
+        if lookupOperatorMethod(right, "lt") == nil { return nil, false }
+        left, right = right, left
+        operator = map[string]string{"<": ">", ">": "<", "<=": ">=", ">=": "<="}[operator]
+    }
+
+    switch operator {
+    case "<":
+        return callOperatorMethod("lt", left, right)
+    case ">=":
+        return negate(callOperatorMethod("lt", left, right))
+    case "<=":
+        return lessOrEqual(left, right), true
+    default:
+        return negate(lessOrEqual(left, right), true)
+    }
+}
+// Without eq, the operands are compared field by field
+func lessOrEqual(left, right object.Object) object.Object {
+    less, _ := callOperatorMethod("lt", left, right)
+    if isError(less) || less == object.TRUE { return less }
+
+    if equal, ok := callOperatorMethod("eq", left, right); ok {
+        return equal
+    }
+    return nativeBoolToObject(object.Equals(left, right))
+}
+func negate(result object.Object, ok bool) (object.Object, bool) {
+    if !ok || isError(result) { return result, ok }
+    return nativeBoolToObject(result != object.TRUE), true
+}
+func callOperatorMethod(name string, receiver, argument object.Object) (object.Object, bool) {
+    method := lookupOperatorMethod(receiver, name)
+    if method == nil { return nil, false }
+
+    result := method(argument)
+    if isError(result) || (name != "lt" && name != "eq" && name != "contains") {
+        return result, true
+    }
+
+    if _, ok := result.(*object.Bool); !ok {
+        return object.NewError("%s.%s must return bool, got %s", builtins.Type(receiver).Inspect(), name, object.TypeName[result.Type()]), true
+    }
+    return result, true
+}
+// The operator method name of receiver, bound to it. Structs define them
+// in Kimchi, and vecs and sets in Go.
+func lookupOperatorMethod(receiver object.Object, name string) func(argument object.Object) object.Object {
+    switch receiver := receiver.(type) {
+    case *object.Struct:
+        method, ok := receiver.Definition.Methods[name]
+        if !ok { return nil }
+        return func(argument object.Object) object.Object {
+            return applyStructMethod(receiver, method, []object.Object{argument})
+        }
+    case *object.Vec:
+        if operator, ok := arithmeticOperators[name]; ok {
+            return func(argument object.Object) object.Object {
+                return evalVecInfixExpression(operator, receiver, argument)
+            }
+        }
+        if name == "eq" {
+            return func(argument object.Object) object.Object {
+                return nativeBoolToObject(object.Equals(receiver, argument))
+            }
+        }
+    case *object.Set:
+        switch name {
+        case "eq":
+            return func(argument object.Object) object.Object {
+                return nativeBoolToObject(object.Equals(receiver, argument))
+            }
+        case "contains":
+            return func(argument object.Object) object.Object {
+                return nativeBoolToObject(receiver.Contains(argument))
+            }
+        }
+    }
+    return nil
+}
+
+// =======
+// VECTORS
+// =======
+func evalVecLiteral(node *ast.VecLiteral, env *object.Environment) object.Object {
+    elements := evalExpressions(node.Elements, env)
+    if len(elements) == 1 && isError(elements[0]) { return elements[0] }
+
+    vec, ok := object.NewVec(elements)
+    if !ok {
+        return object.NewError("vec elements must be all i64 or all f64")
+    }
+    return vec
+}
+func evalVecIndexExpression(vec, index object.Object) object.Object {
+    vecObject := vec.(*object.Vec)
+    idx := index.(*object.I64).Value
+
+    if idx < 0 || idx >= int64(vecObject.Len()) {
+        return object.NewError("index out of range: %d", idx)
+    }
+    return vecObject.At(int(idx))
+}
+// Operates two vectors element by element. A scalar on either side is
+// broadcast to the length of the vector.
+func evalVecInfixExpression(operator string, left, right object.Object) object.Object {
+    switch operator {
+    case "is", "is_not":
+        return evalEqualityInfixExpression(operator, left, right)
+    case "+", "-", "*", "/":
+    default:
+        return object.NewError("unknown operator: %s %s %s", vecTypeName(left), operator, vecTypeName(right))
+    }
+
+    leftVec, leftOk := left.(*object.Vec)
+    rightVec, rightOk := right.(*object.Vec)
+    if !leftOk {
+        leftVec = broadcast(left, rightVec)
+    }
+    if !rightOk {
+        rightVec = broadcast(right, leftVec)
+    }
+    if leftVec == nil || rightVec == nil || leftVec.Subtype != rightVec.Subtype {
+        return object.NewError("cannot operate the values: %s %s %s", vecTypeName(left), operator, vecTypeName(right))
+    }
+    if leftVec.Len() != rightVec.Len() {
+        return object.NewError("vec lengths differ: %d and %d", leftVec.Len(), rightVec.Len())
+    }
+
+    result := &object.Vec{Subtype: leftVec.Subtype}
+    if result.Subtype == object.F64_OBJ {
+        result.Floats = make([]float64, leftVec.Len())
+        for i, l := range leftVec.Floats {
+            r := rightVec.Floats[i]
+            switch operator {
+            case "+": result.Floats[i] = l + r
+            case "-": result.Floats[i] = l - r
+            case "*": result.Floats[i] = l * r
+            case "/": result.Floats[i] = l / r
+            }
+        }
+        return result
+    }
+
+    result.Ints = make([]int64, leftVec.Len())
+    for i, l := range leftVec.Ints {
+        r := rightVec.Ints[i]
+        switch operator {
+        case "+": result.Ints[i] = l + r
+        case "-": result.Ints[i] = l - r
+        case "*": result.Ints[i] = l * r
+        case "/":
+            if r == 0 { return object.NewError("division by zero") }
+            result.Ints[i] = l / r
+        }
+    }
+    return result
+}
+// Repeats a scalar to the length of vec, or returns nil if the types differ
+func broadcast(scalar object.Object, vec *object.Vec) *object.Vec {
+    if vec == nil { return nil }
+
+    switch scalar := scalar.(type) {
+    case *object.I64:
+        if vec.Subtype != object.I64_OBJ { return nil }
+        result := &object.Vec{Subtype: object.I64_OBJ, Ints: make([]int64, vec.Len())}
+        for i := range result.Ints {
+            result.Ints[i] = scalar.Value
+        }
+        return result
+    case *object.F64:
+        if vec.Subtype != object.F64_OBJ { return nil }
+        result := &object.Vec{Subtype: object.F64_OBJ, Floats: make([]float64, vec.Len())}
+        for i := range result.Floats {
+            result.Floats[i] = scalar.Value
+        }
+        return result
+    }
+    return nil
+}
+func vecTypeName(obj object.Object) string {
+    if vec, ok := obj.(*object.Vec); ok {
+        return "vec(" + object.TypeName[vec.Subtype] + ")"
+    }
+    return object.TypeName[obj.Type()]
 }
 
 // =========
 // FUNCTIONS
 // =========
 func applyFunction(fn object.Object, args []object.Object) object.Object {
+    switch fn.(type) {
+    case *object.Function, *object.StructType:
+    default:
+        if hasNamedArguments(args) {
+            return object.NewError("named arguments can only be passed to functions and structs")
+        }
+    }
+
     switch fn := fn.(type) {
     case *object.Function:
-        extendedEnv := extendFunctionEnv(fn, args)
+        extendedEnv, err := extendFunctionEnv(fn, args)
+        if err != nil { return err }
         evaluated := Eval(fn.Body, extendedEnv)
         return unwrapReturnValue(evaluated)
     case *object.BuiltIn:
@@ -535,16 +1040,114 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
         return evalIndexExpression(fn, args[0])
     case *object.Str:
         return evalIndexExpression(fn, args[0])
+    case *object.Tuple:
+        return evalIndexExpression(fn, args[0])
+    case *object.Vec:
+        return evalIndexExpression(fn, args[0])
+    case *object.StructType:
+        return newStruct(fn, args)
     default:
         return object.NewError("not a function: %s", object.TypeName[fn.Type()])
     }
 }
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+// Binds the arguments to the parameters. Arguments can be passed by name,
+// missing ones take their default value, and the ones left over are
+// collected in a list by a trailing variadic parameter.
+func extendFunctionEnv(fn *object.Function, args []object.Object) (*object.Environment, *object.Error) {
     env := object.NewEnclosedEnvironment(fn.Env)
-    for paramIdx, param := range fn.Parameters {
-        env.Set(param.Name, args[paramIdx])
+
+    parameters := fn.Parameters
+    var variadic *ast.Identifier
+    if len(parameters) > 0 && parameters[len(parameters)-1].Variadic {
+        variadic = parameters[len(parameters)-1]
+        parameters = parameters[:len(parameters)-1]
+    }
+
+    values := make([]object.Object, len(parameters))
+    rest := []object.Object{}
+    position := 0
+    sawNamed := false
+    for _, arg := range args {
+        if named, ok := arg.(*object.NamedArgument); ok {
+            sawNamed = true
+            index := parameterIndex(parameters, named.Name)
+            if index < 0 {
+                return nil, object.NewError("unknown argument %s", named.Name)
+            }
+            if values[index] != nil {
+                return nil, object.NewError("argument %s given twice", named.Name)
+            }
+            values[index] = named.Value
+            continue
+        }
+
+        if sawNamed {
+            return nil, object.NewError("positional argument after named arguments")
+        }
+        switch {
+        case position < len(values):
+            values[position] = arg
+        case variadic != nil:
+            rest = append(rest, arg)
+        default:
+            return nil, object.NewError("too many arguments: expected at most %d, got %d", len(parameters), len(args))
+        }
+        position++
     }
-    return env
+
+    // Defaults are evaluated in order, so they can use the parameters before them
+    for i, parameter := range parameters {
+        if values[i] == nil {
+            if parameter.Default == nil {
+                return nil, object.NewError("missing argument %s", parameter.Name)
+            }
+            values[i] = Eval(parameter.Default, env)
+            if err, ok := values[i].(*object.Error); ok { return nil, err }
+        }
+        env.Set(parameter.Name, values[i])
+    }
+    if variadic != nil {
+        env.Set(variadic.Name, &object.List{Elements: rest})
+    }
+
+    if err := checkInterfaceArguments(fn, env); err != nil { return nil, err }
+    return env, nil
+}
+func parameterIndex(parameters []*ast.Identifier, name string) int {
+    for i, parameter := range parameters {
+        if parameter.Name == name {
+            return i
+        }
+    }
+    return -1
+}
+func hasNamedArguments(args []object.Object) bool {
+    for _, arg := range args {
+        if arg.Type() == object.NAMED_ARGUMENT_OBJ {
+            return true
+        }
+    }
+    return false
+}
+// Parameters typed with an interface only accept structs that implement it
+func checkInterfaceArguments(fn *object.Function, env *object.Environment) *object.Error {
+    for _, parameter := range fn.Parameters {
+        if parameter.Variadic || parameter.Type == nil || parameter.Type.Type.Type != token.IDENTIFIER {
+            continue
+        }
+        definition, ok := fn.Env.Get(parameter.Type.Type.Literal)
+        if !ok { continue }
+        iface, ok := definition.(*object.InterfaceType)
+        if !ok { continue }
+
+        value, _ := env.Get(parameter.Name)
+        if instance, ok := value.(*object.Struct); ok && instance.Definition.Implements(iface) {
+            continue
+        }
+        return object.NewError("argument %s must implement %s, got %s",
+            parameter.Name, iface.Name, builtins.Type(value).Inspect())
+    }
+    return nil
 }
 func unwrapReturnValue(obj object.Object) object.Object {
     if returnValue, ok := obj.(*object.Return); ok {
@@ -552,35 +1155,47 @@ func unwrapReturnValue(obj object.Object) object.Object {
     }
     return obj
 }
+// Any function can be called as a method of its first argument, so that
+// x.f(y) is the same as f(x, y)
 func applyMethod(left object.Object, method object.Object, args []object.Object) object.Object {
     switch method := method.(type) {
     case *object.BuiltIn:
+        if hasNamedArguments(args) {
+            return object.NewError("named arguments can only be passed to functions and structs")
+        }
         return method.Function(append([]object.Object{left}, args...)...)
+    case *object.Function:
+        return applyFunction(method, append([]object.Object{left}, args...))
     default:
-        return object.NewError("not a method: %d", method.Type())
+        return object.NewError("not a method: %s", object.TypeName[method.Type()])
     }
 }
+func applyStructMethod(instance *object.Struct, method *object.Function, args []object.Object) object.Object {
+    extendedEnv, err := extendFunctionEnv(method, args)
+    if err != nil { return err }
+    extendedEnv.Set("self", instance)
+    evaluated := Eval(method.Body, extendedEnv)
+    return unwrapReturnValue(evaluated)
+}
 
 // ===========
 // COLLECTIONS
 // ===========
 func evalMapLiteral(node *ast.MapLiteral, env *object.Environment) object.Object {
-    pairs := make(map[object.MapKey]object.MapPair)
+    mapObject := object.NewMap()
 
-    for keyNode, valueNode := range node.Pairs {
-        key := Eval(keyNode, env)
+    for _, pair := range node.Pairs {
+        key := Eval(pair.Key, env)
         if isError(key) { return key }
 
-        mapKey, ok := key.(object.Hashable)
-        if !ok { return object.NewError("unusable as map key: %d", key.Type()) }
+        if _, ok := key.(object.Hashable); !ok { return object.NewError("unusable as map key: %d", key.Type()) }
 
-        value := Eval(valueNode, env)
+        value := Eval(pair.Value, env)
         if isError(value) { return value }
 
-        hashed := mapKey.MapKey()
-        pairs[hashed] = object.MapPair{Key: key, Value: value}
+        mapObject.Set(key, value)
     }
-    return &object.Map{Pairs: pairs}
+    return mapObject
 }
 func evalMapIndexExpression(mapObj, index object.Object) object.Object {
     mapObject := mapObj.(*object.Map)
@@ -592,16 +1207,305 @@ func evalMapIndexExpression(mapObj, index object.Object) object.Object {
 
     return pair.Value
 }
-func evalStructLiteral(node *ast.StructLiteral, env *object.Environment) object.Object {
-    fields := make(map[string]object.Object)
+func evalSetLiteral(node *ast.SetLiteral, env *object.Environment) object.Object {
+    elements := evalExpressions(node.Elements, env)
+    if len(elements) == 1 && isError(elements[0]) { return elements[0] }
+
+    set := object.NewSet()
+    for _, element := range elements {
+        if !set.Add(element) {
+            return object.NewError("unusable as set element: %s", object.TypeName[element.Type()])
+        }
+    }
+    return set
+}
+func evalStructLiteral(node *ast.StructLiteral, env *object.Environment, name string) object.Object {
+    for i, field := range node.Fields {
+        if field.Default != nil || field.Variadic {
+            return object.NewError("struct field %s cannot have a default value or be variadic", field.Name)
+        }
+        for _, other := range node.Fields[:i] {
+            if field.Name == other.Name {
+                return object.NewError("duplicate struct field: %s", field.Name)
+            }
+        }
+    }
+
+    definition := &object.StructType{Name: name, Fields: node.Fields, Methods: map[string]*object.Function{}}
+    for _, method := range node.Methods {
+        name := method.Identifier.Name
+        if definition.HasField(name) {
+            return object.NewError("method %s has the same name as a field", name)
+        }
+        if _, ok := definition.Methods[name]; ok {
+            return object.NewError("duplicate struct method: %s", name)
+        }
+        definition.Methods[name] = Eval(method.Expression, env).(*object.Function)
+    }
 
-    for _, fieldNode := range node.Fields {
-        field := Eval(fieldNode, env)
-        if isError(field) { return field }
+    for _, identifier := range node.Interfaces {
+        obj := Eval(identifier, env)
+        if isError(obj) { return obj }
 
+        iface, ok := obj.(*object.InterfaceType)
+        if !ok {
+            return object.NewError("%s is not an interface", identifier.Name)
+        }
+        if err := checkImplementation(definition, iface); err != nil { return err }
+        definition.Interfaces = append(definition.Interfaces, iface)
     }
 
-    return &object.Struct{Fields: fields}
+    return definition
+}
+func checkImplementation(definition *object.StructType, iface *object.InterfaceType) *object.Error {
+    name := definition.Name
+    if name == "" { name = "struct" }
+
+    for _, signature := range iface.Methods {
+        method, ok := definition.Methods[signature.Name]
+        if !ok {
+            return object.NewError("%s does not implement %s: missing method %s", name, iface.Name, signature.Name)
+        }
+        // A bare fn accepts any method
+        if signature.Type.String() != "fn" && method.Signature() != signature.Type.String() {
+            return object.NewError("%s does not implement %s: method %s has type %s, expected %s",
+                name, iface.Name, signature.Name, method.Signature(), signature.Type.String())
+        }
+    }
+    return nil
+}
+func evalInterfaceLiteral(node *ast.InterfaceLiteral, env *object.Environment) object.Object {
+    for i, method := range node.Methods {
+        for _, other := range node.Methods[:i] {
+            if method.Name == other.Name {
+                return object.NewError("duplicate interface method: %s", method.Name)
+            }
+        }
+    }
+
+    return &object.InterfaceType{Methods: node.Methods}
+}
+func newStruct(definition *object.StructType, args []object.Object) object.Object {
+    if len(args) != len(definition.Fields) {
+        return object.NewError("%s takes %d fields, got %d", definition.Name, len(definition.Fields), len(args))
+    }
+
+    // Fields can be given by name, like arguments to functions
+    fields := make(map[string]object.Object, len(args))
+    sawNamed := false
+    for i, arg := range args {
+        name := definition.Fields[i].Name
+        if named, ok := arg.(*object.NamedArgument); ok {
+            sawNamed = true
+            if parameterIndex(definition.Fields, named.Name) < 0 {
+                return object.NewError("%s has no field %s", definition.Name, named.Name)
+            }
+            name, arg = named.Name, named.Value
+        } else if sawNamed {
+            return object.NewError("positional argument after named arguments")
+        }
+
+        if _, ok := fields[name]; ok {
+            return object.NewError("argument %s given twice", name)
+        }
+        fields[name] = arg
+    }
+
+    return &object.Struct{Definition: definition, Fields: fields}
+}
+func evalEnumLiteral(node *ast.EnumLiteral, env *object.Environment) object.Object {
+    for i, variant := range node.Variants {
+        for _, other := range node.Variants[:i] {
+            if variant.Name == other.Name {
+                return object.NewError("duplicate enum variant: %s", variant.Name)
+            }
+        }
+    }
+
+    return &object.EnumType{Variants: node.Variants}
+}
+func evalEnumVariant(enum *object.EnumType, node *ast.DotExpression, args []object.Object) object.Object {
+    name := node.Method.(*ast.Identifier).Name
+    variant := enum.Variant(name)
+    if variant == nil {
+        return object.NewError("%s has no variant %s", enum.Name, name)
+    }
+
+    // Variants with a payload are constructors until they are called
+    if len(variant.Fields) > 0 && node.Arguments == nil {
+        return &object.BuiltIn{Function: func(args ...object.Object) object.Object {
+            return newEnumValue(enum, variant, args)
+        }}
+    }
+
+    return newEnumValue(enum, variant, args)
+}
+func newEnumValue(enum *object.EnumType, variant *ast.EnumVariant, args []object.Object) object.Object {
+    if len(args) != len(variant.Fields) {
+        return object.NewError("%s.%s takes %d values, got %d", enum.Name, variant.Name, len(variant.Fields), len(args))
+    }
+
+    return &object.EnumValue{Enum: enum, Variant: variant, Values: args}
+}
+
+// ========
+// MATCHING
+// ========
+func evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+    subject := Eval(node.Subject, env)
+    if isError(subject) { return subject }
+
+    if value, ok := subject.(*object.EnumValue); ok {
+        missing := missingVariants(node, value.Enum, env)
+        if len(missing) > 0 {
+            return object.NewError("non-exhaustive match: %s not covered", strings.Join(missing, ", "))
+        }
+    }
+
+    for _, arm := range node.Arms {
+        bindings := make(map[string]object.Object)
+        matched, err := matchPattern(arm.Pattern, subject, env, bindings)
+        if err != nil { return err }
+        if !matched { continue }
+
+        // The names bound by the pattern only live in the arm
+        armEnv := object.NewEnclosedEnvironment(env)
+        for name, value := range bindings {
+            armEnv.Set(name, value)
+        }
+
+        if arm.Guard != nil {
+            guard := Eval(arm.Guard, armEnv)
+            if isError(guard) { return guard }
+            if !isTruthy(guard) { continue }
+        }
+
+        result := Eval(arm.Body, armEnv)
+        if result == nil { return object.NONE }
+        return result
+    }
+
+    return object.NewError("no pattern matches %s", subject.Inspect())
+}
+// Reports whether pattern matches value, collecting the names it binds.
+// Patterns that are neither bindings, enum variants nor lists are
+// evaluated and compared with value.
+func matchPattern(pattern ast.Expression, value object.Object, env *object.Environment, bindings map[string]object.Object) (bool, object.Object) {
+    switch pattern := pattern.(type) {
+    case *ast.Identifier:
+        if pattern.Name != "_" {
+            bindings[pattern.Name] = value
+        }
+        return true, nil
+
+    case *ast.ListLiteral:
+        list, ok := value.(*object.List)
+        if !ok { return false, nil }
+        return matchElements(pattern.Elements, list.Elements, env, bindings)
+
+    case *ast.TupleLiteral:
+        tuple, ok := value.(*object.Tuple)
+        if !ok { return false, nil }
+        return matchElements(pattern.Elements, tuple.Elements, env, bindings)
+
+    case *ast.RestPattern:
+        return false, object.NewError("rest patterns must be the last element of a list pattern")
+
+    case *ast.DotExpression:
+        left := Eval(pattern.Left, env)
+        if isError(left) { return false, left }
+
+        if enum, ok := left.(*object.EnumType); ok {
+            return matchVariant(pattern, enum, value, env, bindings)
+        }
+    }
+
+    expected := Eval(pattern, env)
+    if isError(expected) { return false, expected }
+
+    return object.Equals(expected, value), nil
+}
+func matchVariant(pattern *ast.DotExpression, enum *object.EnumType, value object.Object, env *object.Environment, bindings map[string]object.Object) (bool, object.Object) {
+    name := pattern.Method.(*ast.Identifier).Name
+    variant := enum.Variant(name)
+    if variant == nil {
+        return false, object.NewError("%s has no variant %s", enum.Name, name)
+    }
+
+    actual, ok := value.(*object.EnumValue)
+    if !ok || actual.Variant != variant { return false, nil }
+
+    // Shape.Circle matches a circle of any radius
+    if pattern.Arguments == nil { return true, nil }
+
+    if len(pattern.Arguments) != len(variant.Fields) {
+        return false, object.NewError("%s.%s has %d values, pattern has %d", enum.Name, variant.Name, len(variant.Fields), len(pattern.Arguments))
+    }
+    return matchElements(pattern.Arguments, actual.Values, env, bindings)
+}
+func matchElements(patterns []ast.Expression, values []object.Object, env *object.Environment, bindings map[string]object.Object) (bool, object.Object) {
+    var rest *ast.RestPattern
+    if len(patterns) > 0 {
+        rest, _ = patterns[len(patterns)-1].(*ast.RestPattern)
+    }
+    if rest != nil {
+        patterns = patterns[:len(patterns)-1]
+    }
+
+    if len(values) < len(patterns) || (rest == nil && len(values) != len(patterns)) {
+        return false, nil
+    }
+
+    for i, pattern := range patterns {
+        matched, err := matchPattern(pattern, values[i], env, bindings)
+        if err != nil || !matched { return matched, err }
+    }
+
+    if rest != nil && rest.Identifier.Name != "_" {
+        elements := make([]object.Object, len(values) - len(patterns))
+        copy(elements, values[len(patterns):])
+        bindings[rest.Identifier.Name] = &object.List{Elements: elements}
+    }
+
+    return true, nil
+}
+// Variants of enum that are not covered by any arm without a guard. Arms
+// that only bind the payload of a variant cover it, and an arm that is a
+// single binding or _ covers every variant.
+func missingVariants(node *ast.MatchExpression, enum *object.EnumType, env *object.Environment) []string {
+    covered := make(map[*ast.EnumVariant]bool)
+
+    for _, arm := range node.Arms {
+        if arm.Guard != nil { continue }
+
+        switch pattern := arm.Pattern.(type) {
+        case *ast.Identifier:
+            return nil
+        case *ast.DotExpression:
+            if Eval(pattern.Left, env) != enum || !bindsOnly(pattern.Arguments) { continue }
+            if variant := enum.Variant(pattern.Method.(*ast.Identifier).Name); variant != nil {
+                covered[variant] = true
+            }
+        }
+    }
+
+    missing := []string{}
+    for _, variant := range enum.Variants {
+        if !covered[variant] {
+            missing = append(missing, enum.Name + "." + variant.Name)
+        }
+    }
+    return missing
+}
+func bindsOnly(patterns []ast.Expression) bool {
+    for _, pattern := range patterns {
+        switch pattern.(type) {
+        case *ast.Identifier, *ast.RestPattern:
+        default:
+            return false
+        }
+    }
+    return true
 }
 
 // =====
@@ -632,15 +1536,24 @@ func evalForExpression(fe *ast.ForExpression, env *object.Environment) object.Ob
     iterator, ok := iterable.(object.Iterable)
     if !ok { return object.NewError("object is not iterable: %d", iterable.Type()) }
 
+    mapObject, isMap := iterable.(*object.Map)
+
     var result object.Object
     var index int = -1
     for true {
         index++
+        // Maps can hold none, so they end after their last key instead
+        if isMap && index >= len(mapObject.Keys) { break }
         element := iterator.Next(index)
-        if element == object.NONE { break }
+        if !isMap && element == object.NONE { break }
 
         if fe.Index.Name != "_" {
-            env.Set(fe.Index.Name, &object.I64{Value: int64(index)})
+            // Maps bind their keys instead of the position
+            if isMap {
+                env.Set(fe.Index.Name, mapObject.KeyAt(index))
+            } else {
+                env.Set(fe.Index.Name, &object.I64{Value: int64(index)})
+            }
         }
         if fe.Value.Name != "_" {
             env.Set(fe.Value.Name, element)
@@ -1,7 +1,11 @@
 package evaluator
 
 import (
+    "os"
+    "path/filepath"
+    "strings"
     "testing"
+    "kimchi/checker"
     "kimchi/object"
     "kimchi/tokenizer"
     "kimchi/parser"
@@ -27,6 +31,9 @@ func TestEvalIntegerExpression(t *testing.T) {
         {"3 * 3 * 3 + 10", 37},
         {"3 * (3 * 3) + 10", 37},
         {"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+        {"0xff + 0b1010 + 0o17", 280},
+        {"1_000_000 / 1_000", 1000},
+        {"5.as_str().len()", 1},
     }
 
     for _, tt := range tests {
@@ -54,6 +61,8 @@ func TestEvalFloatExpression(t *testing.T) {
         {"2.5 * (5.5 + 10.5)", 2.5 * (5.5 + 10.5)},
         {"3.5 * 3.5 * 3.5 + 10.5", 3.5*3.5*3.5 + 10.5},
         {"3.5 * (3.5 * 3.5) + 10.5", 3.5 * (3.5 * 3.5) + 10.5},
+        {"1e3 + 2.5e-1", 1000.25},
+        {"1_000.5 * 2.0", 2001},
     }
 
     for _, tt := range tests {
@@ -110,6 +119,33 @@ func TestEvalStringExpression(t *testing.T) {
     }
 }
 
+func TestInterpolatedStrings(t *testing.T) {
+    tests := []struct {
+        input string
+        expected string
+    }{
+        {`let x be 41 "total: {x + 1}"`, "total: 42"},
+        {`let name be "kimchi" let s: str = "hi {name}, {name.len()} letters" s`, "hi kimchi, 6 letters"},
+        {`"{list(1, 2)} and {true}"`, "[1, 2] and true"},
+        {`"nested {"a{1 + 1}b"}"`, "nested a2b"},
+        {`"literal \{braces\}"`, "literal {braces}"},
+        {`"a \{b\} c"`, "a {b} c"},
+        {`r"a {b} c"`, "a {b} c"},
+        {`let f be fn(x: i64): str { return "x={x}" } f(3)`, "x=3"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        testStringObject(t, evaluated, tt.expected)
+    }
+
+    // Unescaped braces always interpolate
+    evaluated := testEval(`"a {b} c"`)
+    if err, ok := evaluated.(*object.Error); !ok || err.Message != "identifier not found: b" {
+        t.Errorf("braces were not interpolated. got=%s", evaluated.Inspect())
+    }
+}
+
 func TestNotOperator(t *testing.T) {
     tests := []struct {
         input string
@@ -188,6 +224,12 @@ func TestLetStatements(t *testing.T) {
         {"let a: i64 = 5 * 5 a", 25},
         {"let a: i64 = 5 let b: i64 = a b", 5},
         {"let a: i64 = 5 let b: i64 = a let c: i64 = a + b + 5 c", 15},
+        {"let a be 5 let b be a * 2 b", 10},
+        {"let f be fn(x: i64): i64 { return x + 1 } let r be f(2) r", 3},
+        {"let xs be list(4, 5) let n be len(xs) n", 2},
+        {"let a be (5) a", 5},
+        {"let a be -5 a", -5},
+        {"let xs be (list(4, 5)) let n be len(xs) n", 2},
     }
 
     for _, tt := range tests {
@@ -334,9 +376,9 @@ func TestListIndexExpressions(t *testing.T) {
         {"list(1, 2, 3)(2)", 3},
         {"let i: i64 = 0 list(1)(i)", 1},
         {"list(1, 2, 3)(1 + 1)", 3},
-        {"let my_list: list = list(1, 2, 3) my_list(2)", 3},
-        {"let my_list: list = list(1, 2, 3) my_list(0) + my_list(1) + my_list(2)", 6},
-        {"let my_list: list = list(1, 2, 3) let i: i64 = my_list(0) my_list(i)", 2},
+        {"let my_list: list(i64) = list(1, 2, 3) my_list(2)", 3},
+        {"let my_list: list(i64) = list(1, 2, 3) my_list(0) + my_list(1) + my_list(2)", 6},
+        {"let my_list: list(i64) = list(1, 2, 3) let i: i64 = my_list(0) my_list(i)", 2},
     }
 
     for _, tt := range tests {
@@ -377,6 +419,45 @@ func TestStringIndexExpressions(t *testing.T) {
     }
 }
 
+func TestUnicodeStrings(t *testing.T) {
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`len("김치")`, 2},
+        {`"añejo".len()`, 5},
+        {`"김치 ok"(1)`, "치"},
+        {`"añejo"(1 to 3)`, "ñe"},
+        {`"añejo"(-1)`, "index out of range: -1"},
+        {`"김치".bytes().len()`, 6},
+        {`"añ".bytes()(2)`, 177},
+        {`
+        let result: list(str) = list()
+        for _, c in "añ치" {
+            mut result to .append(c)
+        }
+        result.join("|")
+        `, "a|ñ|치"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case string:
+            if errObj, ok := evaluated.(*object.Error); ok {
+                if errObj.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+                }
+                continue
+            }
+            testStringObject(t, evaluated, expected)
+        }
+    }
+}
+
 func TestMapLiterals(t *testing.T) {
     input := `
     let two: str = "two"
@@ -670,20 +751,956 @@ func TestListOperations(t *testing.T) {
     }
 }
 
-// func TestStructs(t *testing.T) {
-//     input := `
-//     let Person be struct(
-//         name: string,
-//         age: i64
-//     )
+func TestErrorPositions(t *testing.T) {
+    tests := []struct {
+        input string
+        expected string
+    }{
+        {"let a be 5\n\nlet b: i64 = a + c", "main.kimchi:3:18: identifier not found: c"},
+        {"let a be 5\nlet b be \"b\"\n  a + b", "main.kimchi:3:5: cannot operate the values: i64 + str"},
+        {"let f be fn(): i64 {\n    return list(1)(3)\n}\nf()", "main.kimchi:2:19: index out of range: 3"},
+    }
+
+    for _, tt := range tests {
+        tokenizer := tokenizer.NewFile("main.kimchi", tt.input)
+        parser := parser.New(tokenizer)
+        program := parser.ParseProgram()
+
+        evaluated := Eval(program, object.NewEnvironment())
+        errObj, ok := evaluated.(*object.Error)
+        if !ok {
+            t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+            continue
+        }
+        if errObj.Inspect() != tt.expected {
+            t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errObj.Inspect())
+        }
+    }
+}
+
+func TestCompoundTypeAnnotations(t *testing.T) {
+    tests := []struct {
+        input string
+        expected int64
+    }{
+        {"let grid: list(list(i64)) = list(list(1, 2), list(3)) grid(1)(0)", 3},
+        {"let m: map(str, list(i64)) = map(\"a\": list(1, 2)) m(\"a\")(1)", 2},
+        {"let add: fn(i64, i64): i64 = fn(a: i64, b: i64): i64 { return a + b } add(1, 2)", 3},
+        {"let apply be fn(f: fn(i64): i64, x: i64): i64 { return f(x) } apply(fn(x: i64): i64 { return x * 2 }, 4)", 8},
+        {"let xs be list(1, 2, 3) xs(2)", 3},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        testIntegerObject(t, evaluated, tt.expected)
+    }
+}
+
+func TestStructs(t *testing.T) {
+    definitions := `
+    let Person be struct(
+        name: str,
+        age: i64
+    )
+    let Team be struct(lead: Person, members: list(str))
+    `
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`let p: Person = Person("John", 20) p.name`, "John"},
+        {`let p: Person = Person("John", 20) p.age + 1`, 21},
+        {`let p: Person = Person("John", 20) mut p.age to 30 p.age`, 30},
+        {`let p: Person = Person("John", 20) mut p.age to + 1 p.age`, 21},
+        {`let t: Team = Team(Person("Ana", 30), list("a", "b")) mut t.lead.name to "Eva" t.lead.name`, "Eva"},
+        {`let t: Team = Team(Person("Ana", 30), list("a", "b")) mut t.members(1) to "c" t.members(1)`, "c"},
+        {`let p: Person = Person("John", 20) let q: Person = p mut q.age to 1 p.age`, 20},
+        {`Person("John", 20)`, "Person(name: John, age: 20)"},
+        {`Person("John", 20).type()`, "Person"},
+        {`Person`, "struct Person(name: str, age: i64)"},
+        {`struct(x: i64)(1)`, "struct(x: 1)"},
+        {`Person("John")`, "Person takes 2 fields, got 1"},
+        {`Person("John", 20).height`, "Person has no field height"},
+        {`let x be 5 mut x.y to 1`, "expected struct, got i64"},
+        {`struct(x: i64, x: i64)`, "duplicate struct field: x"},
+        {`Person(age: 20, name: "John")`, "Person(name: John, age: 20)"},
+        {`Person("John", age: 20).age`, 20},
+        {`Person(name: "John", 20)`, "positional argument after named arguments"},
+        {`Person("John", name: "Eva")`, "argument name given twice"},
+        {`Person("John", height: 2)`, "Person has no field height"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(definitions + tt.input)
+
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case string:
+            if errObj, ok := evaluated.(*object.Error); ok {
+                if errObj.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+                }
+                continue
+            }
+            if evaluated.Inspect() != expected {
+                t.Errorf("wrong result. expected=%q, got=%q", expected, evaluated.Inspect())
+            }
+        }
+    }
+}
+
+func TestEnums(t *testing.T) {
+    definitions := `
+    let Shape be enum(
+        Circle(radius: f64),
+        Rect(w: f64, h: f64),
+        Empty,
+    )
+    `
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`Shape.Circle(1.5)`, "Shape.Circle(1.500000)"},
+        {`Shape.Empty`, "Shape.Empty"},
+        {`Shape.Empty.type()`, "Shape"},
+        {`Shape.Empty is Shape.Empty`, true},
+        {`Shape.Circle(1.0) is Shape.Circle(2.0)`, false},
+        {`Shape.Rect(1.0, 2.0) is_not Shape.Empty`, true},
+        {`let make: fn = Shape.Circle make(2.0)`, "Shape.Circle(2.000000)"},
+        {`Shape.Square`, "Shape has no variant Square"},
+        {`Shape.Circle(1.0, 2.0)`, "Shape.Circle takes 1 values, got 2"},
+        {`enum(A, A)`, "duplicate enum variant: A"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(definitions + tt.input)
+
+        switch expected := tt.expected.(type) {
+        case bool:
+            testBooleanObject(t, evaluated, expected)
+        case string:
+            if errObj, ok := evaluated.(*object.Error); ok {
+                if errObj.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+                }
+                continue
+            }
+            if evaluated.Inspect() != expected {
+                t.Errorf("wrong result. expected=%q, got=%q", expected, evaluated.Inspect())
+            }
+        }
+    }
+}
+
+func TestMatchExpressions(t *testing.T) {
+    definitions := `
+    let Shape be enum(
+        Circle(radius: i64),
+        Rect(w: i64, h: i64),
+        Empty,
+    )
+    let area be fn(s: Shape): i64 {
+        return match s {
+            Shape.Circle(r): 3 * r * r
+            Shape.Rect(w, h) if w is h: { w * w }
+            Shape.Rect(w, h): w * h
+            Shape.Empty: 0
+        }
+    }
+    let describe be fn(xs: list(i64)): str {
+        return match xs {
+            list(): "empty"
+            list(x): "one {x}"
+            list(1, ...rest): "one and {rest.len()} more"
+            list(a, b, ..._): "{a} and {b} first"
+        }
+    }
+    `
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`area(Shape.Circle(2))`, 12},
+        {`area(Shape.Rect(3, 3))`, 9},
+        {`area(Shape.Rect(2, 5))`, 10},
+        {`area(Shape.Empty)`, 0},
+        {`describe(list())`, "empty"},
+        {`describe(list(7))`, "one 7"},
+        {`describe(list(1, 2, 3))`, "one and 2 more"},
+        {`describe(list(4, 5, 6))`, "4 and 5 first"},
+        {`match 2 { 1: "one", 2: "two", _: "many" }`, "two"},
+        {`match "b" { "a": 1, "b": 2, _: 3 }`, 2},
+        {`match -1 { -1: "minus one", _: "other" }`, "minus one"},
+        {`match 10 { x if x > 5: x * 2, x: x }`, 20},
+        {`match 3 { x if x > 5: x * 2, x: x }`, 3},
+        {`let limit be 4 match 4 { limit: "bound" }`, "bound"},
+        {`match true { false: 0, true: 1 }`, 1},
+        {`match Shape.Circle(1) { Shape.Circle: "a circle", _: "other" }`, "a circle"},
+        {`let total be 0 match 5 { n: { mut total to n } } total`, 5},
+        {`let x: i64 = 5 match 3 { x: x + 1 } x`, 5},
+        {`let y: i64 = 10 match 7 { y if y > 100: 1, _: 2 } y`, 10},
+        {`match 5 { 1: "one" }`, "no pattern matches 5"},
+        {`match Shape.Empty { Shape.Circle(r): r }`, "non-exhaustive match: Shape.Rect, Shape.Empty not covered"},
+        {`match Shape.Empty { Shape.Circle(r) if r > 1: r, Shape.Rect(w, h): w, Shape.Empty: 0 }`, "non-exhaustive match: Shape.Circle not covered"},
+        {`match Shape.Rect(1, 2) { Shape.Rect(w): w, _: 0 }`, "Shape.Rect has 2 values, pattern has 1"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(definitions + tt.input)
+
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case string:
+            if errObj, ok := evaluated.(*object.Error); ok {
+                if errObj.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+                }
+                continue
+            }
+            testStringObject(t, evaluated, expected)
+        }
+    }
+}
+
+func TestTuples(t *testing.T) {
+    definitions := `
+    let divmod be fn(a: i64, b: i64): tuple(i64, i64) { return a / b, a - a / b * b }
+    `
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`tuple(1, "a")`, "(1, a)"},
+        {`tuple(1, "a")(1)`, "a"},
+        {`tuple(1, "a", true).len()`, 3},
+        {`let q, r be divmod(7, 2) q * 10 + r`, 31},
+        {`let _, r be divmod(9, 4) r`, 1},
+        {`let a, b be 1, 2 a * 10 + b`, 12},
+        {`let a, b be list(3, 4) a + b`, 7},
+        {`let x be 1 let y be 2 mut x, y to y, x x * 10 + y`, 21},
+        {`let total be 0 for _, v in tuple(1, 2, 3) { mut total to + v } total`, 6},
+        {`let m be map(tuple(1, 2): "a", tuple(2, 1): "b") m(tuple(2, 1))`, "b"},
+        {`tuple(1, 2) is tuple(1, 2)`, true},
+        {`tuple(1, 2) is_not tuple(2, 1)`, true},
+        {`match tuple(1, 5) { tuple(1, b): b, _: 0 }`, 5},
+        {`let p: tuple(str, i64) = tuple("a", 1) p(1)`, 1},
+        {`tuple(1)(3)`, "index out of range: 3"},
+        {`let a, b be 1`, "cannot destructure i64"},
+        {`let a, b be 1, 2, 3`, "expected 2 values to destructure, got 3"},
+        {`let a be 1 let b be 2 mut a, b to 1, 2, 3`, "expected 2 values to destructure, got 3"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(definitions + tt.input)
+
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case bool:
+            testBooleanObject(t, evaluated, expected)
+        case string:
+            if errObj, ok := evaluated.(*object.Error); ok {
+                if errObj.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+                }
+                continue
+            }
+            if evaluated.Inspect() != expected {
+                t.Errorf("wrong value. expected=%q, got=%q", expected, evaluated.Inspect())
+            }
+        }
+    }
+}
+
+func TestSets(t *testing.T) {
+    definitions := `
+    let a: set(i64) = set(1, 2, 3, 2)
+    let b be set(3, 4)
+    `
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`a`, "set(1, 2, 3)"},
+        {`a.len()`, 3},
+        {`set().len()`, 0},
+        {`a.union(b)`, "set(1, 2, 3, 4)"},
+        {`a.intersection(b)`, "set(3)"},
+        {`a.difference(b)`, "set(1, 2)"},
+        {`set(1, 2).is_subset(a)`, true},
+        {`a.is_subset(b)`, false},
+        {`a.is_superset(set(3))`, true},
+        {`a.has(2)`, true},
+        {`a.has(5)`, false},
+        {`set(tuple(1, 2)).has(tuple(1, 2))`, true},
+        {`set(2, 1) is set(1, 2)`, true},
+        {`set(1) is_not set(1, 2)`, true},
+        {`let total be 0 for _, v in a { mut total to + v } total`, 6},
+        {`let c: set(i64) = a mut c to .union(b) a.len()`, 3},
+        {`type(a)`, "set"},
+        {`set(list(1))`, "unusable as set element: list"},
+        {`a.union(list(1))`, "argument to `union` must be SET, got list"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(definitions + tt.input)
+
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case bool:
+            testBooleanObject(t, evaluated, expected)
+        case string:
+            if errObj, ok := evaluated.(*object.Error); ok {
+                if errObj.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+                }
+                continue
+            }
+            if evaluated.Inspect() != expected {
+                t.Errorf("wrong value. expected=%q, got=%q", expected, evaluated.Inspect())
+            }
+        }
+    }
+}
+
+func TestVecs(t *testing.T) {
+    definitions := `
+    let a: vec(i64) = vec(1, 2, 3)
+    let b be vec(4, 5, 6)
+    `
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`a + b`, "vec(5, 7, 9)"},
+        {`b - a`, "vec(3, 3, 3)"},
+        {`a * b`, "vec(4, 10, 18)"},
+        {`b / a`, "vec(4, 2, 2)"},
+        {`a * 2`, "vec(2, 4, 6)"},
+        {`10 - a`, "vec(9, 8, 7)"},
+        {`vec(1.0, 2.0) / 4.0`, "vec(0.250000, 0.500000)"},
+        {`a.dot(b)`, 32},
+        {`vec(3.0, 4.0).norm()`, 5.0},
+        {`vec(3, 4).norm()`, 5.0},
+        {`a(2)`, 3},
+        {`a.len()`, 3},
+        {`a.as_list()`, "[1, 2, 3]"},
+        {`list(1.5, 2.5).as_vec()`, "vec(1.500000, 2.500000)"},
+        {`a.as_f64() * 0.5`, "vec(0.500000, 1.000000, 1.500000)"},
+        {`a is vec(1, 2, 3)`, true},
+        {`a is_not b`, true},
+        {`let total be 0 for _, v in a { mut total to + v } total`, 6},
+        {`type(a)`, "vec"},
+        {`vec(1, 2.0)`, "vec elements must be all i64 or all f64"},
+        {`vec("a")`, "vec elements must be all i64 or all f64"},
+        {`a + vec(1, 2)`, "vec lengths differ: 3 and 2"},
+        {`a + 1.5`, "cannot operate the values: vec(i64) + f64"},
+        {`a + vec(1.0, 2.0, 3.0)`, "cannot operate the values: vec(i64) + vec(f64)"},
+        {`a / vec(1, 0, 1)`, "division by zero"},
+        {`a < b`, "unknown operator: vec(i64) < vec(i64)"},
+        {`a(3)`, "index out of range: 3"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(definitions + tt.input)
+
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case float64:
+            testFloatObject(t, evaluated, expected)
+        case bool:
+            testBooleanObject(t, evaluated, expected)
+        case string:
+            if errObj, ok := evaluated.(*object.Error); ok {
+                if errObj.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+                }
+                continue
+            }
+            if evaluated.Inspect() != expected {
+                t.Errorf("wrong value. expected=%q, got=%q", expected, evaluated.Inspect())
+            }
+        }
+    }
+}
+
+func TestStructMethods(t *testing.T) {
+    definitions := `
+    let Point be struct(x: i64, y: i64) {
+        let sum be fn(): i64 { return self.x + self.y }
+        let double be fn(): i64 { return self.sum() * 2 }
+        let dot be fn(other: Point): i64 { return self.x * other.x + self.y * other.y }
+        let move be fn(dx: i64, dy: i64): none {
+            mut self.x to + dx
+            mut self.y to + dy
+        }
+    }
+    let p: Point = Point(1, 2)
+    `
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`p.sum()`, 3},
+        {`p.double()`, 6},
+        {`p.dot(Point(3, 4))`, 11},
+        {`exe p.move(2, 3) p`, "Point(x: 3, y: 5)"},
+        {`p.move(1, 1) p.x`, 2},
+        {`let q: Point = p exe q.move(1, 1) p.x`, 1},
+        {`p.nope()`, "Point has no field nope"},
+        {`let A be struct(x: i64) { let x be fn(): i64 { return 1 } }`, "method x has the same name as a field"},
+        {`let A be struct(x: i64) { let f be fn(): i64 { return 1 } let f be fn(): i64 { return 2 } }`, "duplicate struct method: f"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(definitions + tt.input)
+
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case string:
+            if errObj, ok := evaluated.(*object.Error); ok {
+                if errObj.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+                }
+                continue
+            }
+            if evaluated.Inspect() != expected {
+                t.Errorf("wrong value. expected=%q, got=%q", expected, evaluated.Inspect())
+            }
+        }
+    }
+}
+
+func TestUniformFunctionCallSyntax(t *testing.T) {
+    definitions := `
+    let add be fn(a: i64, b: i64): i64 { return a + b }
+    let double be fn(xs: list(i64)): list(i64) {
+        let out: list(i64) = list()
+        for _, x in xs { mut out to .append(x * 2) }
+        return out
+    }
+    let Point be struct(x: i64, y: i64)
+    let norm be fn(p: Point): i64 { return p.x * p.x + p.y * p.y }
+    let xs: list(i64) = list(3, 1, 2)
+    `
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`1.add(2)`, 3},
+        {`1.add(2).add(3)`, 6},
+        {`xs.double()`, "[6, 2, 4]"},
+        {`xs.double().sort().reverse()`, "[6, 4, 2]"},
+        {`xs.sort().double()`, "[2, 4, 6]"},
+        {`Point(1, 2).norm()`, 5},
+        {`1.xs()`, "not a method: list"},
+        {`1.missing()`, "identifier not found: missing"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(definitions + tt.input)
+
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case string:
+            if errObj, ok := evaluated.(*object.Error); ok {
+                if errObj.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+                }
+                continue
+            }
+            if evaluated.Inspect() != expected {
+                t.Errorf("wrong value. expected=%q, got=%q", expected, evaluated.Inspect())
+            }
+        }
+    }
+}
+
+func TestInterfaces(t *testing.T) {
+    definitions := `
+    let Shape be interface(area: fn(): i64, name: fn(): str)
+    let Square be struct(s: i64) is Shape {
+        let area be fn(): i64 { return self.s * self.s }
+        let name be fn(): str { return "square" }
+    }
+    let Rect be struct(w: i64, h: i64) is Shape {
+        let area be fn(): i64 { return self.w * self.h }
+        let name be fn(): str { return "rect" }
+    }
+    let Point be struct(x: i64)
+    let describe be fn(s: Shape): str { return "{s.name()} {s.area()}" }
+    `
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`describe(Square(2))`, "square 4"},
+        {`Rect(2, 3).describe()`, "rect 6"},
+        {`Shape`, "interface Shape(area: fn(): i64, name: fn(): str)"},
+        {`type(Shape)`, "interface"},
+        {`describe(Point(1))`, "argument s must implement Shape, got Point"},
+        {`describe(1)`, "argument s must implement Shape, got i64"},
+        {`let A be struct(x: i64) is Shape { let area be fn(): i64 { return 1 } }`, "A does not implement Shape: missing method name"},
+        {`let A be struct(x: i64) is Shape {
+            let area be fn(): str { return "" }
+            let name be fn(): str { return "" }
+        }`, "A does not implement Shape: method area has type fn(): str, expected fn(): i64"},
+        {`let A be struct(x: i64) is Point`, "Point is not an interface"},
+        {`let I be interface(f: fn, f: fn)`, "duplicate interface method: f"},
+        {`let Any be interface(f: fn) let A be struct(x: i64) is Any { let f be fn(y: i64): i64 { return y } } A(1).f(2)`, 2},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(definitions + tt.input)
+
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case string:
+            if errObj, ok := evaluated.(*object.Error); ok {
+                if errObj.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+                }
+                continue
+            }
+            if evaluated.Inspect() != expected {
+                t.Errorf("wrong value. expected=%q, got=%q", expected, evaluated.Inspect())
+            }
+        }
+    }
+}
+
+func TestOperatorOverloading(t *testing.T) {
+    definitions := `
+    let Money be struct(cents: i64) {
+        let add be fn(other: Money): Money { return Money(self.cents + other.cents) }
+        let mul be fn(n: i64): Money { return Money(self.cents * n) }
+        let lt be fn(other: Money): bool { return self.cents < other.cents }
+        let eq be fn(other: Money): bool { return self.cents / 100 is other.cents / 100 }
+    }
+    let Bad be struct(x: i64) {
+        let lt be fn(other: Bad): i64 { return 1 }
+    }
+    let Meter be struct(v: i64) {
+        let lt be fn(other: i64): bool { return self.v < other }
+    }
+    let a: Money = Money(150)
+    let b: Money = Money(199)
+    `
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`a + b`, "Money(cents: 349)"},
+        {`a * 3`, "Money(cents: 450)"},
+        {`let total: Money = Money(0) mut total to + a total`, "Money(cents: 150)"},
+        {`a < b`, true},
+        {`a > b`, false},
+        {`a <= b`, true},
+        {`a >= b`, false},
+        {`b >= b`, true},
+        {`a is b`, true},
+        {`a is_not Money(250)`, true},
+        {`Bad(1) is Bad(1)`, true},
+        {`Meter(3) < 5`, true},
+        {`Meter(3) > 5`, false},
+        {`Meter(7) > 5`, true},
+        {`Meter(3) >= 5`, false},
+        {`Meter(3) <= 5`, true},
+        {`vec(1, 2) + vec(3, 4)`, "vec(4, 6)"},
+        {`set(1, 2) is set(2, 1)`, true},
+        {`a - b`, "Money has no method sub for operator -"},
+        {`Bad(1) < Bad(2)`, "Bad.lt must return bool, got i64"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(definitions + tt.input)
+
+        switch expected := tt.expected.(type) {
+        case bool:
+            testBooleanObject(t, evaluated, expected)
+        case string:
+            if errObj, ok := evaluated.(*object.Error); ok {
+                if errObj.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+                }
+                continue
+            }
+            if evaluated.Inspect() != expected {
+                t.Errorf("wrong value. expected=%q, got=%q", expected, evaluated.Inspect())
+            }
+        }
+    }
+}
+
+func TestFunctionArguments(t *testing.T) {
+    definitions := `
+    let greet be fn(name: str, greeting: str = "hello", times: i64 = 1): str {
+        return "{greeting} {name} {times}"
+    }
+    let total be fn(start: i64, xs: ...i64): i64 {
+        let sum: i64 = start
+        for _, x in xs { mut sum to + x }
+        return sum
+    }
+    let twice be fn(a: i64, b: i64 = a * 2): i64 { return a + b }
+    `
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`greet("ana")`, "hello ana 1"},
+        {`greet("ana", "hi")`, "hi ana 1"},
+        {`greet("ana", times: 3)`, "hello ana 3"},
+        {`greet(times: 2, name: "bo")`, "hello bo 2"},
+        {`"ana".greet(greeting: "hey")`, "hey ana 1"},
+        {`total(1)`, 1},
+        {`total(1, 2, 3, 4)`, 10},
+        {`twice(1)`, 3},
+        {`twice(1, 1)`, 2},
+        {`greet()`, "missing argument name"},
+        {`greet("a", "b", 1, 2)`, "too many arguments: expected at most 3, got 4"},
+        {`greet("a", nope: 1)`, "unknown argument nope"},
+        {`greet("a", name: "b")`, "argument name given twice"},
+        {`greet(name: "a", "b")`, "positional argument after named arguments"},
+        {`len(x: "a")`, "named arguments can only be passed to functions and structs"},
+        {`let P be struct(x: i64 = 1)`, "struct field x cannot have a default value or be variadic"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(definitions + tt.input)
+
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case string:
+            if errObj, ok := evaluated.(*object.Error); ok {
+                if errObj.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+                }
+                continue
+            }
+            testStringObject(t, evaluated, expected)
+        }
+    }
+}
+
+func TestInExpressions(t *testing.T) {
+    definitions := `
+    let Bag be struct(items: list(i64)) {
+        let contains be fn(x: i64): bool { return x in self.items }
+    }
+    `
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {`2 in list(1, 2, 3)`, true},
+        {`4 in list(1, 2, 3)`, false},
+        {`not (4 in list(1, 2, 3))`, true},
+        {`"ab" in "cabd"`, true},
+        {`"x" in "cabd"`, false},
+        {`"a" in map("a": 1)`, true},
+        {`1 in map("a": 1)`, false},
+        {`2 in set(1, 2)`, true},
+        {`tuple(1, 2) in list(tuple(1, 2))`, true},
+        {`"a" in tuple(1, "a")`, true},
+        {`2 in vec(1, 2)`, true},
+        {`2.0 in vec(1, 2)`, false},
+        {`5 in (0 to 10)`, true},
+        {`10 in (0 to 10)`, false},
+        {`2 in Bag(list(1, 2))`, true},
+        {`Bag(list()) in list(Bag(list()))`, true},
+        {`1 in "abc"`, "cannot search i64 in str"},
+        {`1 in 2`, "in operator not supported for i64"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(definitions + tt.input)
+
+        switch expected := tt.expected.(type) {
+        case bool:
+            testBooleanObject(t, evaluated, expected)
+        case string:
+            errObj, ok := evaluated.(*object.Error)
+            if !ok {
+                t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+                continue
+            }
+            if errObj.Message != expected {
+                t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+            }
+        }
+    }
+}
+
+func TestMapOrder(t *testing.T) {
+    tests := []struct {
+        input string
+        expected string
+    }{
+        { `map("c": 1, "a": 2, "b": 3)`, "map(c: 1, a: 2, b: 3)", },
+        { `map(3: 1, 1: 2, 3: 4)`, "map(3: 4, 1: 2)", },
+        { `let keys: list(str) = list()
+           for key, _ in map("z": 1, "y": 2, "x": 3) {
+               mut keys to keys + list(key)
+           }
+           keys`, "[z, y, x]", },
+        { `let total be 0
+           for _, value in map("a": 1, "b": 2, "c": 3) {
+               mut total to total + value
+           }
+           total`, "6", },
+        { `let f be fn(x: i64): none { pass }
+           let count be 0
+           for _, _ in map("a": f(1), "b": f(2)) {
+               mut count to count + 1
+           }
+           count`, "2", },
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        if evaluated.Inspect() != tt.expected {
+            t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+        }
+    }
+}
+
+func TestMapMethods(t *testing.T) {
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        { `let m: map(str, i64) = map("a": 1)
+           mut m("b") to 2
+           mut m("a") to 3
+           m`, "map(a: 3, b: 2)", },
+        { `let m: map(str, i64) = map("a": 1)
+           let n: map(str, i64) = m
+           mut n("b") to 2
+           len(m)`, 1, },
+        { `let m: map(str, i64) = map("a": 1)
+           mut m(list(1)) to 2`, "unusable as map key: list", },
+        { `let s: str = "ab"
+           mut s(0) to "c"`, "expected LIST or MAP, got str", },
+        { `let xs: list(i64) = list(1, 2)
+           mut xs(10) to 1`, "index out of range: 10", },
+        { `let xs: list(i64) = list(1, 2)
+           mut xs(-1) to 1`, "index out of range: -1", },
+        { `map("b": 1, "a": 2).keys()`, "[b, a]", },
+        { `map("b": 1, "a": 2).values()`, "[1, 2]", },
+        { `map("b": 1, "a": 2).items()`, "[(b, 1), (a, 2)]", },
+        { `map("a": 1).has("a")`, true, },
+        { `map("a": 1).has("b")`, false, },
+        { `map("a": map()("x")).has("a")`, true, },
+        { `map("a": 1).get("a", 0)`, 1, },
+        { `map("a": 1).get("b", 0)`, 0, },
+        { `map("a": map()("x")).get("a", 0)`, "none", },
+        { `map("a": 1, "b": 2, "c": 3).remove("b")`, "map(a: 1, c: 3)", },
+        { `map("a": 1).remove("z")`, "map(a: 1)", },
+        { `map("a": 1, "b": 2).merge(map("b": 3, "c": 4))`, "map(a: 1, b: 3, c: 4)", },
+        { `len(map("a": 1, "b": 2))`, 2, },
+        { `keys(list(1))`, "argument to `keys` must be MAP, got list", },
+        { `map("a": 1).get("a")`, "wrong number of arguments. got=2, want=3", },
+        { `map("a": 1).merge(set(1))`, "argument to `merge` must be MAP, got set", },
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case bool:
+            testBooleanObject(t, evaluated, expected)
+        case string:
+            if err, ok := evaluated.(*object.Error); ok {
+                if err.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, err.Message)
+                }
+            } else if evaluated.Inspect() != expected {
+                t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, expected, evaluated.Inspect())
+            }
+        }
+    }
+}
+
+func TestModules(t *testing.T) {
+    dir := t.TempDir()
+    writeFile(t, filepath.Join(dir, "shapes", "geom.kimchi"), `
+    use "../consts"
+    let _square be fn(x: i64): i64 { return x * x }
+    let area be fn(side: i64): i64 { return _square(side) * consts.SCALE }
+    `)
+    writeFile(t, filepath.Join(dir, "consts.kimchi"), `let SCALE: i64 = 2`)
+    writeFile(t, filepath.Join(dir, "a.kimchi"), `use "b"`)
+    writeFile(t, filepath.Join(dir, "b.kimchi"), `use "a"`)
+    writeFile(t, filepath.Join(dir, "broken.kimchi"), `let x: i64 = 1 + true`)
+    writeFile(t, filepath.Join(dir, "lib", "strs.kimchi"), `let shout be fn(s: str): str { return s + "!" }`)
+    t.Setenv("KIMCHI_PATH", filepath.Join(dir, "lib"))
+
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        { `use "shapes/geom" geom.area(3)`, 18, },
+        { `use "shapes/geom.kimchi" exe geom.area(1)`, 2, },
+        { `use "consts" consts.SCALE`, 2, },
+        { `use "strs" strs.shout("hi")`, "hi!", },
+        { `use "shapes/geom" geom`, "module geom", },
+        { `use "shapes/geom" geom._square(2)`, "_square is private to module geom", },
+        { `use "shapes/geom" geom.volume(2)`, "module geom has no member volume", },
+        { `use "missing"`, "module not found: missing.kimchi", },
+        { `use "a"`, "import cycle: a.kimchi -> b.kimchi -> a.kimchi", },
+        { `use "broken"`, "cannot operate the values: i64 + bool", },
+    }
+
+    for _, tt := range tests {
+        evaluated := testEvalFile(filepath.Join(dir, "main.kimchi"), tt.input)
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case string:
+            if err, ok := evaluated.(*object.Error); ok {
+                if err.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, err.Message)
+                }
+            } else if evaluated.Inspect() != expected {
+                t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, expected, evaluated.Inspect())
+            }
+        }
+    }
+
+    // Errors point into the module
+    evaluated := testEvalFile(filepath.Join(dir, "main.kimchi"), `use "broken"`)
+    if err, ok := evaluated.(*object.Error); !ok || filepath.Base(err.Position.File) != "broken.kimchi" {
+        t.Errorf("error is not positioned in broken.kimchi. got=%s", evaluated.Inspect())
+    }
+
+    // Modules are type checked before they run
+    writeFile(t, filepath.Join(dir, "typed.kimchi"), `let x: i64 = "a"`)
+    evaluated = testEvalFile(filepath.Join(dir, "main.kimchi"), `use "typed"`)
+    if err, ok := evaluated.(*object.Error); !ok || !strings.HasPrefix(err.Message, "cannot check module typed:\nerror[C001]: cannot assign str to x of type i64") {
+        t.Errorf("module was not type checked. got=%s", evaluated.Inspect())
+    }
+
+    // The checker reports the errors of used modules before anything runs
+    program := parser.New(tokenizer.NewFile(filepath.Join(dir, "main.kimchi"), `print(1) use "typed" use "missing"`)).ParseProgram()
+    checker := checker.New()
+    checker.Modules = CheckModule
+    errors := checker.Check(program)
+    if len(errors) != 2 {
+        t.Fatalf("wrong number of module errors. expected=2, got=%d (%v)", len(errors), errors)
+    }
+    if !strings.HasPrefix(errors[0].Message, "cannot check module typed:") || errors[0].Position.Column != 10 {
+        t.Errorf("wrong error for typed. got=%s", errors[0].Error())
+    }
+    if errors[1].Message != "module not found: missing.kimchi" {
+        t.Errorf("wrong error for missing. got=%s", errors[1].Error())
+    }
+
+    // Modules are evaluated once
+    first := testEvalFile(filepath.Join(dir, "main.kimchi"), `use "consts" consts`)
+    second := testEvalFile(filepath.Join(dir, "other.kimchi"), `use "consts" consts`)
+    if first != second {
+        t.Errorf("module was loaded twice")
+    }
+}
+
+func TestStandardLibrary(t *testing.T) {
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        { `flatten(list(list(1, 2), list(), list(3)))`, "[1, 2, 3]", },
+        { `list(list(1), list(2)).flatten()`, "[1, 2]", },
+        { `chunk(list(1, 2, 3, 4, 5), 2)`, "[[1, 2], [3, 4], [5]]", },
+        { `chunk(list(), 2)`, "[]", },
+        { `zip(list(1, 2, 3), list("a", "b"))`, "[(1, a), (2, b)]", },
+        { `let flatten be fn(x: i64): i64 { return x } flatten(1)`, 1, },
+        { `use "math" math.pow(2.0, 10)`, 1024.0, },
+        { `use "math" math.abs(-1.5)`, 1.5, },
+        { `use "math" math.clamp(-3.0, 0.0, 1.0)`, 0.0, },
+        { `use "text" text.repeat("ab", 3)`, "ababab", },
+        { `use "text" text.pad_left("7", 3, "0")`, "007", },
+        { `use "text" text.pad_right("7", 3) + "|"`, "7  |", },
+        { `use "text" text.pad_left("7", 3, "")`, "7", },
+        { `use "text" text.pad_right("7", 3, "") + "|"`, "7|", },
+        { `use "prelude"`, "module not found: prelude.kimchi", },
+    }
 
-//     let p be Person("John", 20)
-//     p.name
-//     `
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case float64:
+            testFloatObject(t, evaluated, expected)
+        case string:
+            if err, ok := evaluated.(*object.Error); ok {
+                if err.Message != expected {
+                    t.Errorf("wrong error message. expected=%q, got=%q", expected, err.Message)
+                }
+            } else if evaluated.Inspect() != expected {
+                t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, expected, evaluated.Inspect())
+            }
+        }
+    }
+
+    // Local files are found before the standard library
+    dir := t.TempDir()
+    writeFile(t, filepath.Join(dir, "math.kimchi"), `let PI: i64 = 3`)
+    testIntegerObject(t, testEvalFile(filepath.Join(dir, "main.kimchi"), `use "math" math.PI`), 3)
+}
 
-//     evaluated := testEval(input)
-//     testStringObject(t, evaluated, "John")
-// }
+func TestGenericFunctions(t *testing.T) {
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {"let first be fn[T](xs: list(T)): T { return xs(0) } first(list(4, 5))", 4},
+        {`let first be fn[T](xs: list(T)): T { return xs(0) } first(list("a", "b"))`, "a"},
+        {"let pair be fn[A, B](a: A, b: B): tuple(A, B) { return tuple(a, b) } pair(1, true)", "(1, true)"},
+        {"let apply be fn[T](x: T, f: fn(T): T): T { return f(x) } apply(2, fn(x: i64): i64 { return x * 3 })", 6},
+        {"let first be fn[T](xs: list(T)): T { return xs(0) } list(2.5).first()", 2.5},
+        {"flatten(list(list(1, 2), list(3)))", "[1, 2, 3]"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        switch expected := tt.expected.(type) {
+        case int:
+            testIntegerObject(t, evaluated, int64(expected))
+        case float64:
+            testFloatObject(t, evaluated, expected)
+        case string:
+            if str, ok := evaluated.(*object.Str); ok {
+                testStringObject(t, str, expected)
+            } else if evaluated.Inspect() != expected {
+                t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+            }
+        }
+    }
+}
 
 // =======
 // HELPERS
@@ -697,6 +1714,20 @@ func testEval(input string) object.Object {
 
     return Eval(program, env)
 }
+func testEvalFile(filename, input string) object.Object {
+    parser := parser.New(tokenizer.NewFile(filename, input))
+    program := parser.ParseProgram()
+
+    return Eval(program, object.NewEnvironment())
+}
+func writeFile(t *testing.T, path, content string) {
+    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+        t.Fatal(err)
+    }
+}
 func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
     result, ok := obj.(*object.I64)
     if !ok {
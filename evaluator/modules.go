@@ -0,0 +1,203 @@
+package evaluator
+
+import (
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "kimchi/ast"
+    "kimchi/checker"
+    "kimchi/object"
+    "kimchi/parser"
+    "kimchi/std"
+    "kimchi/tokenizer"
+)
+
+const EXTENSION = ".kimchi"
+
+// Paths of the modules embedded in the binary
+const STD_PREFIX = "std:"
+
+// Modules are evaluated once, and shared by every file that uses them
+var modules = map[string]*object.Module{}
+
+// Paths of the modules being evaluated, to detect cycles
+var loading = []string{}
+
+// Paths of the modules that passed the checker or are being checked, so
+// that each is checked once and cycles end
+var checked = map[string]bool{}
+
+// Evaluated the first time a name isn't found anywhere else. An error
+// loading it is kept, and returned on every later lookup.
+var prelude *object.Environment
+var preludeError *object.Error
+
+// =======
+// MODULES
+// =======
+func evalUseStatement(node *ast.UseStatement, env *object.Environment) object.Object {
+    path, err := resolveModule(node.Path, node.Position.File)
+    if err != nil { return err }
+
+    module, err := loadModule(node.Name, path)
+    if err != nil { return err }
+
+    return env.Set(node.Name, module)
+}
+// Checks the module of a use statement and the modules it uses, before the
+// program runs. Import cycles are reported when the modules are evaluated.
+func CheckModule(node *ast.UseStatement) error {
+    path, err := resolveModule(node.Path, node.Position.File)
+    if err != nil { return errors.New(err.Message) }
+    if checked[path] { return nil }
+    checked[path] = true
+
+    content, readError := readModule(path)
+    if readError != nil {
+        delete(checked, path)
+        return fmt.Errorf("cannot read module %s: %s", node.Name, readError)
+    }
+    if _, err := parseModule(node.Name, path, content); err != nil {
+        delete(checked, path)
+        return errors.New(err.Message)
+    }
+    return nil
+}
+// Paths are relative to the file with the use statement, then to each
+// directory of KIMCHI_PATH, and then to the standard library
+func resolveModule(name, importer string) (string, *object.Error) {
+    if !strings.HasSuffix(name, EXTENSION) {
+        name += EXTENSION
+    }
+
+    directories := []string{filepath.Dir(importer)}
+    if filepath.IsAbs(name) {
+        directories = []string{""}
+    }
+    // The standard library only uses its own modules
+    if strings.HasPrefix(importer, STD_PREFIX) {
+        directories = []string{}
+    }
+    directories = append(directories, filepath.SplitList(os.Getenv("KIMCHI_PATH"))...)
+
+    for _, directory := range directories {
+        path := filepath.Join(directory, name)
+        info, err := os.Stat(path)
+        if err != nil || info.IsDir() { continue }
+
+        if absolute, err := filepath.Abs(path); err == nil {
+            path = absolute
+        }
+        return path, nil
+    }
+    if _, ok := std.Module(name); ok {
+        return STD_PREFIX + name, nil
+    }
+    return "", object.NewError("module not found: %s", name)
+}
+func loadModule(name, path string) (*object.Module, *object.Error) {
+    if module, ok := modules[path]; ok {
+        return module, nil
+    }
+
+    for i, loadingPath := range loading {
+        if loadingPath == path {
+            cycle := []string{}
+            for _, cyclePath := range loading[i:] {
+                cycle = append(cycle, filepath.Base(cyclePath))
+            }
+            cycle = append(cycle, filepath.Base(path))
+            return nil, object.NewError("import cycle: %s", strings.Join(cycle, " -> "))
+        }
+    }
+
+    content, err := readModule(path)
+    if err != nil {
+        return nil, object.NewError("cannot read module %s: %s", name, err)
+    }
+
+    program, parseError := parseModule(name, path, content)
+    if parseError != nil { return nil, parseError }
+
+    loading = append(loading, path)
+    defer func() { loading = loading[:len(loading) - 1] }()
+
+    module := &object.Module{Name: name, Path: path, Env: object.NewEnvironment()}
+    result := Eval(program, module.Env)
+    if err, ok := result.(*object.Error); ok {
+        return nil, err
+    }
+
+    modules[path] = module
+    return module, nil
+}
+func readModule(path string) (string, error) {
+    if strings.HasPrefix(path, STD_PREFIX) {
+        content, _ := std.Module(strings.TrimPrefix(path, STD_PREFIX))
+        return content, nil
+    }
+    content, err := ioutil.ReadFile(path)
+    return string(content), err
+}
+func parseModule(name, path, content string) (*ast.Program, *object.Error) {
+    parser := parser.New(tokenizer.NewFile(path, content))
+    program := parser.ParseProgram()
+    if len(parser.Errors) != 0 {
+        rendered := []string{}
+        for _, parseError := range parser.Errors {
+            rendered = append(rendered, parseError.Render(content))
+        }
+        return nil, object.NewError("cannot parse module %s:\n%s", name, strings.Join(rendered, "\n"))
+    }
+
+    checker := checker.New()
+    checker.Modules = CheckModule
+    if errors := checker.Check(program); len(errors) != 0 {
+        rendered := []string{}
+        for _, checkError := range errors {
+            rendered = append(rendered, checkError.Render(content))
+        }
+        return nil, object.NewError("cannot check module %s:\n%s", name, strings.Join(rendered, "\n"))
+    }
+    return program, nil
+}
+// The prelude is part of the standard library, and its names can be used
+// without use. It is only evaluated when a program needs one of them.
+func preludeEnvironment() (*object.Environment, *object.Error) {
+    if prelude != nil { return prelude, preludeError }
+
+    // Set before evaluating, so that names missing in the prelude don't
+    // load it again
+    prelude = object.NewEnvironment()
+
+    program, err := parseModule("prelude", STD_PREFIX + std.PRELUDE, std.Prelude())
+    if err != nil {
+        preludeError = err
+        return prelude, err
+    }
+
+    if result := Eval(program, prelude); isError(result) {
+        preludeError = result.(*object.Error)
+        return prelude, preludeError
+    }
+    return prelude, nil
+}
+// Names starting with _ are private to their module
+func evalModuleMember(module *object.Module, node *ast.DotExpression, args []object.Object) object.Object {
+    name := node.Method.(*ast.Identifier).Name
+    if strings.HasPrefix(name, "_") {
+        return object.NewError("%s is private to module %s", name, module.Name)
+    }
+
+    member, ok := module.Env.Get(name)
+    if !ok {
+        return object.NewError("module %s has no member %s", module.Name, name)
+    }
+    if node.Arguments == nil {
+        return member
+    }
+    return applyFunction(member, args)
+}
@@ -33,6 +33,19 @@ func (e *Environment) Set(name string, val Object) Object {
     return val
 }
 
+// Updates name in the environment that declares it, so that mut inside a
+// match arm changes the variables around the match. Names that aren't
+// declared yet are set in this environment.
+func (e *Environment) Assign(name string, val Object) Object {
+    if _, ok := e.store[name]; !ok && e.outer != nil {
+        if _, ok := e.outer.Get(name); ok {
+            return e.outer.Assign(name, val)
+        }
+    }
+    e.store[name] = val
+    return val
+}
+
 func (e *Environment) Delete(name string) {
     delete(e.store, name)
 }
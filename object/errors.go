@@ -1,13 +1,22 @@
 package object
 
-import "fmt"
+import (
+    "fmt"
+    "kimchi/token"
+)
 
 
 type Error struct {
     Message string
+    Position token.Position
 }
 func (self *Error) Type() int { return ERROR_OBJ }
-func (self *Error) Inspect() string { return self.Message }
+func (self *Error) Inspect() string {
+    if self.Position.IsValid() {
+        return self.Position.String() + ": " + self.Message
+    }
+    return self.Message
+}
 
 func NewError(format string, a ...interface{}) *Error {
     return &Error{Message: fmt.Sprintf(format, a...)}
@@ -22,8 +22,17 @@ const (
     BUILTIN_OBJ
     LIST_OBJ
     MAP_OBJ
+    SET_OBJ
     STRUCT_OBJ
+    STRUCT_TYPE_OBJ
+    ENUM_OBJ
+    ENUM_TYPE_OBJ
+    INTERFACE_OBJ
+    TUPLE_OBJ
+    VEC_OBJ
     SLICE_OBJ
+    NAMED_ARGUMENT_OBJ
+    MODULE_OBJ
     CONTINUE_OBJ
     BREAK_OBJ
 )
@@ -40,8 +49,17 @@ var TypeName = map[int]string{
     BUILTIN_OBJ: "builtin",
     LIST_OBJ: "list",
     MAP_OBJ: "map",
+    SET_OBJ: "set",
     STRUCT_OBJ: "struct",
+    STRUCT_TYPE_OBJ: "type",
+    ENUM_OBJ: "enum",
+    ENUM_TYPE_OBJ: "type",
+    INTERFACE_OBJ: "interface",
+    TUPLE_OBJ: "tuple",
+    VEC_OBJ: "vec",
     SLICE_OBJ: "slice",
+    NAMED_ARGUMENT_OBJ: "named argument",
+    MODULE_OBJ: "module",
     CONTINUE_OBJ: "continue",
     BREAK_OBJ: "break",
 }
@@ -69,6 +87,11 @@ type Hashable interface {
     MapKey() MapKey
 }
 
+// Objects that support the in operator
+type Container interface {
+    Contains(element Object) bool
+}
+
 type Iterable interface {
     Next(i int) Object
 }
@@ -96,6 +119,7 @@ func (self *F64) MapKey() MapKey {
 
 type Str struct {
     Value string
+    runes []rune
 }
 func (self *Str) Type() int { return STR_OBJ }
 func (self *Str) Inspect() string { return self.Value }
@@ -105,11 +129,23 @@ func (self *Str) MapKey() MapKey {
     return MapKey{Type: self.Type(), Value: h.Sum64()}
 }
 func (self *Str) Next(i int) Object {
-    if i < len(self.Value) {
-        return &Str{Value: string(self.Value[i])}
+    runes := self.Runes()
+    if i < len(runes) {
+        return &Str{Value: string(runes[i])}
     }
     return NONE
 }
+// Substrings are contained in the string
+func (self *Str) Contains(element Object) bool {
+    other, ok := element.(*Str)
+    return ok && strings.Contains(self.Value, other.Value)
+}
+func (self *Str) Runes() []rune {
+    if self.runes == nil {
+        self.runes = []rune(self.Value)
+    }
+    return self.runes
+}
 
 type Bool struct {
     Value bool
@@ -137,10 +173,28 @@ func (self *None) Inspect() string { return "none" }
 // =============
 type Function struct {
     Parameters []*ast.Identifier
+    ReturnType *ast.TypeLiteral
     Body *ast.BlockStatement
     Env *Environment
 }
 func (self *Function) Type() int { return FN_OBJ }
+// The type of the function, like fn(i64, str): bool
+func (self *Function) Signature() string {
+    types := []string{}
+    for _, parameter := range self.Parameters {
+        if parameter.Variadic {
+            types = append(types, "..." + parameter.Type.String())
+        } else {
+            types = append(types, parameter.Type.String())
+        }
+    }
+
+    signature := "fn(" + strings.Join(types, ", ") + ")"
+    if self.ReturnType != nil {
+        signature += ": " + self.ReturnType.String()
+    }
+    return signature
+}
 func (self *Function) Inspect() string {
     var out bytes.Buffer
 
@@ -159,10 +213,31 @@ func (self *Function) Inspect() string {
 
 type BuiltIn struct {
     Function BuiltInFunction
+    // The type of the builtin for the checker, written like a function
+    // literal without body, for example fn[T](xs: list(T)): T. Builtins
+    // without one are left unchecked.
+    Signature string
 }
 func (self *BuiltIn) Type() int { return BUILTIN_OBJ }
 func (self *BuiltIn) Inspect() string { return "builtin function" }
 
+// An evaluated argument of a call, like n in f(1, n: 2)
+type NamedArgument struct {
+    Name string
+    Value Object
+}
+func (self *NamedArgument) Type() int { return NAMED_ARGUMENT_OBJ }
+func (self *NamedArgument) Inspect() string { return self.Name + ": " + self.Value.Inspect() }
+
+// A file loaded with use. Its top level names are reached with a dot.
+type Module struct {
+    Name string
+    Path string
+    Env *Environment
+}
+func (self *Module) Type() int { return MODULE_OBJ }
+func (self *Module) Inspect() string { return "module " + self.Name }
+
 type Return struct {
     Value Object
 }
@@ -196,12 +271,132 @@ func (self *List) Next(i int) Object {
     }
     return NONE
 }
+func (self *List) Contains(element Object) bool {
+    return containsElement(self.Elements, element)
+}
 func (self *List) Copy() *List {
     elements := make([]Object, len(self.Elements))
     copy(elements, self.Elements)
     return &List{Elements: elements}
 }
 
+// A fixed size group of values of possibly different types
+type Tuple struct {
+    Elements []Object
+}
+func (self *Tuple) Type() int { return TUPLE_OBJ }
+func (self *Tuple) Inspect() string {
+    var out bytes.Buffer
+
+    out.WriteString("(")
+    for i, element := range self.Elements {
+        out.WriteString(element.Inspect())
+        if i < len(self.Elements) - 1 {
+            out.WriteString(", ")
+        }
+    }
+    out.WriteString(")")
+
+    return out.String()
+}
+func (self *Tuple) Next(i int) Object {
+    if i < len(self.Elements) {
+        return self.Elements[i]
+    }
+    return NONE
+}
+func (self *Tuple) Contains(element Object) bool {
+    return containsElement(self.Elements, element)
+}
+// Combines the keys of the elements. Elements that can't be map keys
+// themselves are hashed by their representation.
+func (self *Tuple) MapKey() MapKey {
+    h := fnv.New64a()
+    for _, element := range self.Elements {
+        if hashable, ok := element.(Hashable); ok {
+            key := hashable.MapKey()
+            fmt.Fprintf(h, "%d:%d;", key.Type, key.Value)
+        } else {
+            fmt.Fprintf(h, "%d:%s;", element.Type(), element.Inspect())
+        }
+    }
+    return MapKey{Type: self.Type(), Value: h.Sum64()}
+}
+
+// A dense vector of numbers. Subtype is I64_OBJ or F64_OBJ, and only the
+// matching slice of values is used.
+type Vec struct {
+    Subtype int
+    Ints []int64
+    Floats []float64
+}
+func NewVec(elements []Object) (*Vec, bool) {
+    vec := &Vec{Subtype: I64_OBJ}
+    if len(elements) > 0 {
+        vec.Subtype = elements[0].Type()
+    }
+
+    for _, element := range elements {
+        switch element := element.(type) {
+        case *I64:
+            if vec.Subtype != I64_OBJ { return nil, false }
+            vec.Ints = append(vec.Ints, element.Value)
+        case *F64:
+            if vec.Subtype != F64_OBJ { return nil, false }
+            vec.Floats = append(vec.Floats, element.Value)
+        default:
+            return nil, false
+        }
+    }
+    return vec, true
+}
+func (self *Vec) Type() int { return VEC_OBJ }
+func (self *Vec) Inspect() string {
+    var out bytes.Buffer
+
+    out.WriteString("vec(")
+    for i := 0; i < self.Len(); i++ {
+        out.WriteString(self.At(i).Inspect())
+        if i < self.Len() - 1 {
+            out.WriteString(", ")
+        }
+    }
+    out.WriteString(")")
+
+    return out.String()
+}
+func (self *Vec) Len() int {
+    if self.Subtype == F64_OBJ {
+        return len(self.Floats)
+    }
+    return len(self.Ints)
+}
+func (self *Vec) At(i int) Object {
+    if self.Subtype == F64_OBJ {
+        return &F64{Value: self.Floats[i]}
+    }
+    return &I64{Value: self.Ints[i]}
+}
+func (self *Vec) Next(i int) Object {
+    if i < self.Len() {
+        return self.At(i)
+    }
+    return NONE
+}
+func (self *Vec) Contains(element Object) bool {
+    if element.Type() != self.Subtype {
+        return false
+    }
+    return containsElement(self.Elements(), element)
+}
+func (self *Vec) Elements() []Object {
+    elements := make([]Object, self.Len())
+    for i := range elements {
+        elements[i] = self.At(i)
+    }
+    return elements
+}
+
 type Slice struct {
     Start int
     End int
@@ -218,6 +413,10 @@ func (self *Slice) Inspect() string {
 
     return out.String()
 }
+func (self *Slice) Contains(element Object) bool {
+    value, ok := element.(*I64)
+    return ok && int64(self.Start) <= value.Value && value.Value < int64(self.End)
+}
     
 // ===========
 // COLLECTIONS
@@ -232,15 +431,85 @@ type MapPair struct {
     Value Object
 }
 
+// The insertion order is kept so that printing and iterating are
+// deterministic. Overwriting a key keeps its original position.
 type Map struct {
+    Keys []MapKey
     Pairs map[MapKey]MapPair
 }
+func NewMap() *Map {
+    return &Map{Pairs: make(map[MapKey]MapPair)}
+}
 func (self *Map) Type() int { return MAP_OBJ }
+// Returns false if the key can't be hashed
+func (self *Map) Set(key, value Object) bool {
+    hashable, ok := key.(Hashable)
+    if !ok { return false }
+
+    hashed := hashable.MapKey()
+    if _, ok := self.Pairs[hashed]; !ok {
+        self.Keys = append(self.Keys, hashed)
+    }
+    self.Pairs[hashed] = MapPair{Key: key, Value: value}
+    return true
+}
+// Maps iterate over their values, KeyAt gives the matching key. A value
+// can be none, so the end is found with len(Keys) instead
+func (self *Map) Next(i int) Object {
+    if i < len(self.Keys) {
+        return self.Pairs[self.Keys[i]].Value
+    }
+    return NONE
+}
+func (self *Map) KeyAt(i int) Object {
+    return self.Pairs[self.Keys[i]].Key
+}
+// The bool is false when the key is missing, so a stored none can be told apart
+func (self *Map) Get(key Object) (Object, bool) {
+    hashable, ok := key.(Hashable)
+    if !ok { return NONE, false }
+
+    pair, ok := self.Pairs[hashable.MapKey()]
+    if !ok { return NONE, false }
+    return pair.Value, true
+}
+func (self *Map) Delete(key Object) {
+    hashable, ok := key.(Hashable)
+    if !ok { return }
+
+    hashed := hashable.MapKey()
+    if _, ok := self.Pairs[hashed]; !ok { return }
+
+    delete(self.Pairs, hashed)
+    for i, k := range self.Keys {
+        if k == hashed {
+            self.Keys = append(self.Keys[:i], self.Keys[i + 1:]...)
+            break
+        }
+    }
+}
+func (self *Map) Copy() *Map {
+    mapObject := NewMap()
+    for _, key := range self.Keys {
+        pair := self.Pairs[key]
+        mapObject.Set(pair.Key, pair.Value)
+    }
+    return mapObject
+}
+// Maps contain their keys
+func (self *Map) Contains(element Object) bool {
+    hashable, ok := element.(Hashable)
+    if !ok { return false }
+
+    _, ok = self.Pairs[hashable.MapKey()]
+    return ok
+}
 func (self *Map) Inspect() string {
     var out bytes.Buffer
 
     pairs := []string{}
-    for _, pair := range self.Pairs {
+    for _, key := range self.Keys {
+        pair := self.Pairs[key]
         pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
     }
 
@@ -251,7 +520,112 @@ func (self *Map) Inspect() string {
     return out.String()
 }
 
+// A set of hashable values. The insertion order is kept so that printing
+// and iterating are deterministic.
+type Set struct {
+    Keys []MapKey
+    Elements map[MapKey]Object
+}
+func NewSet() *Set {
+    return &Set{Elements: make(map[MapKey]Object)}
+}
+func (self *Set) Type() int { return SET_OBJ }
+func (self *Set) Inspect() string {
+    var out bytes.Buffer
+
+    elements := []string{}
+    for _, key := range self.Keys {
+        elements = append(elements, self.Elements[key].Inspect())
+    }
+
+    out.WriteString("set(")
+    out.WriteString(strings.Join(elements, ", "))
+    out.WriteString(")")
+
+    return out.String()
+}
+func (self *Set) Next(i int) Object {
+    if i < len(self.Keys) {
+        return self.Elements[self.Keys[i]]
+    }
+    return NONE
+}
+// Returns false if the element can't be hashed
+func (self *Set) Add(element Object) bool {
+    hashable, ok := element.(Hashable)
+    if !ok { return false }
+
+    key := hashable.MapKey()
+    if _, ok := self.Elements[key]; !ok {
+        self.Keys = append(self.Keys, key)
+        self.Elements[key] = element
+    }
+    return true
+}
+func (self *Set) Has(element Object) bool {
+    hashable, ok := element.(Hashable)
+    if !ok { return false }
+
+    _, ok = self.Elements[hashable.MapKey()]
+    return ok
+}
+func (self *Set) Contains(element Object) bool {
+    return self.Has(element)
+}
+func (self *Set) Copy() *Set {
+    set := NewSet()
+    for _, key := range self.Keys {
+        set.Add(self.Elements[key])
+    }
+    return set
+}
+
+// A struct declaration, like struct(x: i64, y: i64). The name is set when
+// the declaration is bound with let.
+type StructType struct {
+    Name string
+    Fields []*ast.Identifier
+    Methods map[string]*Function
+    Interfaces []*InterfaceType
+}
+func (self *StructType) Type() int { return STRUCT_TYPE_OBJ }
+func (self *StructType) Inspect() string {
+    var out bytes.Buffer
+
+    fields := []string{}
+    for _, field := range self.Fields {
+        fields = append(fields, field.Name + ": " + field.Type.String())
+    }
+
+    out.WriteString("struct")
+    if self.Name != "" {
+        out.WriteString(" " + self.Name)
+    }
+    out.WriteString("(")
+    out.WriteString(strings.Join(fields, ", "))
+    out.WriteString(")")
+
+    return out.String()
+}
+func (self *StructType) HasField(name string) bool {
+    for _, field := range self.Fields {
+        if field.Name == name {
+            return true
+        }
+    }
+    return false
+}
+func (self *StructType) Implements(iface *InterfaceType) bool {
+    for _, other := range self.Interfaces {
+        if other == iface {
+            return true
+        }
+    }
+    return false
+}
+
 type Struct struct {
+    Definition *StructType
     Fields map[string]Object
 }
 func (self *Struct) Type() int { return STRUCT_OBJ }
@@ -259,16 +633,115 @@ func (self *Struct) Inspect() string {
     var out bytes.Buffer
 
     fields := []string{}
-    for name, value := range self.Fields {
-        fields = append(fields, fmt.Sprintf("%s: %s", name, value.Inspect()))
+    for _, field := range self.Definition.Fields {
+        fields = append(fields, fmt.Sprintf("%s: %s", field.Name, self.Fields[field.Name].Inspect()))
     }
 
-    out.WriteString("struct(")
+    if self.Definition.Name != "" {
+        out.WriteString(self.Definition.Name)
+    } else {
+        out.WriteString("struct")
+    }
+    out.WriteString("(")
     out.WriteString(strings.Join(fields, ", "))
     out.WriteString(")")
 
     return out.String()
 }
+func (self *Struct) Copy() *Struct {
+    fields := make(map[string]Object, len(self.Fields))
+    for name, value := range self.Fields {
+        fields[name] = value
+    }
+    return &Struct{Definition: self.Definition, Fields: fields}
+}
+
+// An interface declaration, like interface(area: fn(): f64). The name is
+// set when the declaration is bound with let.
+type InterfaceType struct {
+    Name string
+    Methods []*ast.Identifier
+}
+func (self *InterfaceType) Type() int { return INTERFACE_OBJ }
+func (self *InterfaceType) Inspect() string {
+    var out bytes.Buffer
+
+    methods := []string{}
+    for _, method := range self.Methods {
+        methods = append(methods, method.Name + ": " + method.Type.String())
+    }
+
+    out.WriteString("interface")
+    if self.Name != "" {
+        out.WriteString(" " + self.Name)
+    }
+    out.WriteString("(")
+    out.WriteString(strings.Join(methods, ", "))
+    out.WriteString(")")
+
+    return out.String()
+}
+
+// An enum declaration, like enum(Circle(radius: f64), Empty). The name is
+// set when the declaration is bound with let.
+type EnumType struct {
+    Name string
+    Variants []*ast.EnumVariant
+}
+func (self *EnumType) Type() int { return ENUM_TYPE_OBJ }
+func (self *EnumType) Inspect() string {
+    var out bytes.Buffer
+
+    variants := []string{}
+    for _, variant := range self.Variants {
+        variants = append(variants, variant.String())
+    }
+
+    out.WriteString("enum")
+    if self.Name != "" {
+        out.WriteString(" " + self.Name)
+    }
+    out.WriteString("(")
+    out.WriteString(strings.Join(variants, ", "))
+    out.WriteString(")")
+
+    return out.String()
+}
+func (self *EnumType) Variant(name string) *ast.EnumVariant {
+    for _, variant := range self.Variants {
+        if variant.Name == name {
+            return variant
+        }
+    }
+    return nil
+}
+
+type EnumValue struct {
+    Enum *EnumType
+    Variant *ast.EnumVariant
+    Values []Object
+}
+func (self *EnumValue) Type() int { return ENUM_OBJ }
+func (self *EnumValue) Inspect() string {
+    var out bytes.Buffer
+
+    if self.Enum.Name != "" {
+        out.WriteString(self.Enum.Name + ".")
+    }
+    out.WriteString(self.Variant.Name)
+
+    if len(self.Values) > 0 {
+        values := []string{}
+        for _, value := range self.Values {
+            values = append(values, value.Inspect())
+        }
+        out.WriteString("(")
+        out.WriteString(strings.Join(values, ", "))
+        out.WriteString(")")
+    }
+
+    return out.String()
+}
 
 // ============
 // CONTROL FLOW
@@ -280,3 +753,89 @@ func (self *Break) Inspect() string { return "break" }
 type Continue struct {}
 func (self *Continue) Type() int { return CONTINUE_OBJ }
 func (self *Continue) Inspect() string { return "continue" }
+
+// =======
+// HELPERS
+// =======
+
+// Structural equality, used by is and by match patterns. Objects without
+// a notion of equality are only equal to themselves.
+func Equals(left Object, right Object) bool {
+    if left.Type() != right.Type() {
+        return false
+    }
+
+    switch left := left.(type) {
+    case *I64:
+        return left.Value == right.(*I64).Value
+    case *F64:
+        return left.Value == right.(*F64).Value
+    case *Str:
+        return left.Value == right.(*Str).Value
+    case *Bool:
+        return left.Value == right.(*Bool).Value
+    case *None:
+        return true
+    case *List:
+        return equalElements(left.Elements, right.(*List).Elements)
+    case *Tuple:
+        return equalElements(left.Elements, right.(*Tuple).Elements)
+    case *Vec:
+        other := right.(*Vec)
+        if left.Subtype != other.Subtype || left.Len() != other.Len() {
+            return false
+        }
+        for i := 0; i < left.Len(); i++ {
+            if !Equals(left.At(i), other.At(i)) {
+                return false
+            }
+        }
+        return true
+    case *Set:
+        other := right.(*Set)
+        if len(left.Keys) != len(other.Keys) {
+            return false
+        }
+        for _, key := range left.Keys {
+            if _, ok := other.Elements[key]; !ok {
+                return false
+            }
+        }
+        return true
+    case *EnumValue:
+        other := right.(*EnumValue)
+        return left.Enum == other.Enum && left.Variant == other.Variant && equalElements(left.Values, other.Values)
+    case *Struct:
+        other := right.(*Struct)
+        if left.Definition != other.Definition {
+            return false
+        }
+        for name, value := range left.Fields {
+            if !Equals(value, other.Fields[name]) {
+                return false
+            }
+        }
+        return true
+    default:
+        return left == right
+    }
+}
+func containsElement(elements []Object, element Object) bool {
+    for _, other := range elements {
+        if Equals(other, element) {
+            return true
+        }
+    }
+    return false
+}
+func equalElements(left []Object, right []Object) bool {
+    if len(left) != len(right) {
+        return false
+    }
+    for i := range left {
+        if !Equals(left[i], right[i]) {
+            return false
+        }
+    }
+    return true
+}
@@ -17,3 +17,14 @@ func TestStringMapKey(t *testing.T) {
         t.Errorf("strings with different content have same hash keys")
     }
 }
+func TestTupleMapKey(t *testing.T) {
+    pair1 := &Tuple{Elements: []Object{&I64{Value: 1}, &Str{Value: "a"}}}
+    pair2 := &Tuple{Elements: []Object{&I64{Value: 1}, &Str{Value: "a"}}}
+    swapped := &Tuple{Elements: []Object{&Str{Value: "a"}, &I64{Value: 1}}}
+    if pair1.MapKey() != pair2.MapKey() {
+        t.Errorf("tuples with same content have different hash keys")
+    }
+    if pair1.MapKey() == swapped.MapKey() {
+        t.Errorf("tuples with different content have same hash keys")
+    }
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+    "fmt"
+    "strings"
+    "unicode/utf8"
+    "kimchi/token"
+)
+
+// Error codes
+const (
+    UNEXPECTED_TOKEN = "P001"
+    EXPECTED_EXPRESSION = "P002"
+    ILLEGAL_TOKEN = "P003"
+    INVALID_LITERAL = "P004"
+    INVALID_TYPE = "P005"
+    INVALID_METHOD = "P006"
+)
+
+type ParseError struct {
+    Code string
+    Message string
+    Position token.Position
+    Expected []string
+    Found token.Token
+}
+func (self *ParseError) Error() string {
+    if self.Position.IsValid() {
+        return self.Position.String() + ": " + self.Message
+    }
+    return self.Message
+}
+
+// Renders the error followed by the offending source line, with the found
+// token underlined:
+//
+//     error[P001]: expected ')', found 'let'
+//      --> main.kimchi:2:14
+//       |
+//     2 | let x be f(1
+//       |              ^^^
+func (self *ParseError) Render(source string) string {
+    return RenderError(self.Code, self.Message, self.Position, source, self.width())
+}
+// Shared by the errors of the checker, so that both look the same. The
+// source line is left out when the position is unknown.
+func RenderError(code, message string, position token.Position, source string, width int) string {
+    var out strings.Builder
+    fmt.Fprintf(&out, "error[%s]: %s\n", code, message)
+
+    lines := strings.Split(source, "\n")
+    if !position.IsValid() || position.Line > len(lines) {
+        return out.String()
+    }
+    line := strings.TrimRight(lines[position.Line-1], "\r")
+    number := fmt.Sprint(position.Line)
+    gutter := strings.Repeat(" ", len(number))
+
+    fmt.Fprintf(&out, "%s--> %s\n", gutter, position)
+    fmt.Fprintf(&out, "%s |\n", gutter)
+    fmt.Fprintf(&out, "%s | %s\n", number, line)
+
+    // Keep tabs so that the carets line up with the source line
+    var padding strings.Builder
+    for i, char := range []rune(line) {
+        if i >= position.Column-1 {
+            break
+        }
+        if char == '\t' {
+            padding.WriteRune('\t')
+        } else {
+            padding.WriteRune(' ')
+        }
+    }
+    fmt.Fprintf(&out, "%s | %s%s\n", gutter, padding.String(), strings.Repeat("^", width))
+
+    return out.String()
+}
+
+// Number of columns taken by the found token in the source
+func (self *ParseError) width() int {
+    switch self.Found.Type {
+    case token.EOF, token.ILLEGAL, 0:
+        return 1
+    case token.LITERAL:
+        if self.Found.Subtype != token.I64 && self.Found.Subtype != token.F64 {
+            return 1
+        }
+    }
+    if width := utf8.RuneCountInString(self.Found.Literal); width > 0 {
+        return width
+    }
+    return 1
+}
+
+func describe(found token.Token) string {
+    switch found.Type {
+    case token.EOF:
+        return "end of file"
+    case token.ILLEGAL:
+        return found.Literal
+    }
+    switch found.Subtype {
+    case token.STR, token.TEMPLATE_HEAD:
+        return fmt.Sprintf("string %q", found.Literal)
+    case token.TEMPLATE_MIDDLE, token.TEMPLATE_TAIL:
+        return "string"
+    }
+    return "'" + found.Literal + "'"
+}
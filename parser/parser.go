@@ -1,8 +1,11 @@
 package parser
 
 import (
+    "errors"
     "fmt"
+    "path"
     "strconv"
+    "strings"
     "kimchi/ast"
     "kimchi/tokenizer"
     "kimchi/token"
@@ -28,6 +31,18 @@ const (
     CALL
 )
 
+var statementKeywords = map[int]bool {
+    token.LET: true,
+    token.RETURN: true,
+    token.MUT: true,
+    token.EXE: true,
+    token.USE: true,
+    token.BREAK: true,
+    token.CONTINUE: true,
+    token.FOR: true,
+    token.WHILE: true,
+}
+
 var precedences = map[int]int {
     token.AND: AND,
     token.OR: AND,
@@ -38,6 +53,7 @@ var precedences = map[int]int {
     token.GT: LESSGREATER,
     token.LTE: LESSGREATER,
     token.GTE: LESSGREATER,
+    token.IN: LESSGREATER,
     token.PLUS: SUM,
     token.MINUS: SUM,
     token.SLASH: PRODUCT,
@@ -55,23 +71,28 @@ type Parser struct {
     prefixParseFns map[int]prefixParseFn
     infixParseFns map[int]infixParseFn
 
-    Errors []string
+    Errors []*ParseError
+    // Set after an error until the parser resynchronizes at the next
+    // statement, so that a single mistake is reported only once
+    recovering bool
 }
 
 // ==============
 // PUBLIC METHODS
 // ==============
 func New(tokenizer *tokenizer.Tokenizer) *Parser {
-    parser := &Parser{tokenizer: tokenizer, Errors: []string{}}
+    parser := &Parser{tokenizer: tokenizer, Errors: []*ParseError{}}
 
     parser.nextToken()
     parser.nextToken()
 
     parser.prefixParseFns = make(map[int]prefixParseFn)
     parser.prefixParseFns[token.IDENTIFIER] = parser.parseIdentifier
+    parser.prefixParseFns[token.SELF] = parser.parseIdentifier
     parser.prefixParseFns[token.I64] = parser.parseIntegerLiteral
     parser.prefixParseFns[token.F64] = parser.parseFloatLiteral
     parser.prefixParseFns[token.STR] = parser.parseStringLiteral
+    parser.prefixParseFns[token.TEMPLATE_HEAD] = parser.parseInterpolatedString
     parser.prefixParseFns[token.TRUE] = parser.parseBooleanLiteral
     parser.prefixParseFns[token.FALSE] = parser.parseBooleanLiteral
     parser.prefixParseFns[token.NOT] = parser.parsePrefixExpression
@@ -80,8 +101,16 @@ func New(tokenizer *tokenizer.Tokenizer) *Parser {
     parser.prefixParseFns[token.IF] = parser.parseIfExpression
     parser.prefixParseFns[token.FN] = parser.parseFunctionLiteral
     parser.prefixParseFns[token.STRUCT] = parser.parseStructLiteral
+    parser.prefixParseFns[token.ENUM] = parser.parseEnumLiteral
+    parser.prefixParseFns[token.INTERFACE] = parser.parseInterfaceLiteral
+    parser.prefixParseFns[token.MATCH] = parser.parseMatchExpression
+    parser.prefixParseFns[token.UNDERSCORE] = parser.parseIdentifier
+    parser.prefixParseFns[token.ELLIPSIS] = parser.parseRestPattern
     parser.prefixParseFns[token.LIST] = parser.parseListLiteral
+    parser.prefixParseFns[token.TUPLE] = parser.parseTupleLiteral
+    parser.prefixParseFns[token.VEC] = parser.parseVecLiteral
     parser.prefixParseFns[token.MAP] = parser.parseMapLiteral
+    parser.prefixParseFns[token.SET] = parser.parseSetLiteral
     parser.prefixParseFns[token.WHILE] = parser.parseWhileExpression
     parser.prefixParseFns[token.FOR] = parser.parseForExpression
 
@@ -101,6 +130,7 @@ func New(tokenizer *tokenizer.Tokenizer) *Parser {
     parser.infixParseFns[token.LPAREN] = parser.parseCallExpression
     parser.infixParseFns[token.DOT] = parser.parseDotExpression
     parser.infixParseFns[token.TO] = parser.parseInfixExpression
+    parser.infixParseFns[token.IN] = parser.parseInfixExpression
 
     return parser
 }
@@ -110,13 +140,18 @@ func (self *Parser) ParseProgram() *ast.Program {
 
     for !self.currentTokenIs(token.EOF) {
         if self.currentTokenIs(token.ILLEGAL) {
-            self.addParseError(self.currentToken)
+            self.nextToken()
+            continue
         }
 
         statement := self.parseStatement()
         if statement != nil {
             program.Statements = append(program.Statements, statement)
         }
+        // A stray closing brace is skipped
+        if self.recovering && self.synchronize() && !self.currentTokenIs(token.RBRACE) {
+            continue
+        }
         self.nextToken()
     }
 
@@ -129,9 +164,46 @@ func (self *Parser) ParseProgram() *ast.Program {
 func (self *Parser) nextToken() {
     self.currentToken = self.peekToken
     self.peekToken = self.tokenizer.GetToken()
+
+    if self.peekToken.Type == token.ILLEGAL {
+        self.addIllegalTokenError(self.peekToken)
+    }
+}
+// Skips tokens until the next statement keyword, or until the closing brace
+// of the enclosing block. Braces opened while skipping are balanced. Returns
+// true when the error was found on the current token and that token starts
+// the next statement or closes the block, so it must not be skipped.
+func (self *Parser) synchronize() bool {
+    self.recovering = false
+
+    if len(self.Errors) > 0 && self.Errors[len(self.Errors)-1].Found == self.currentToken {
+        if statementKeywords[self.currentToken.Subtype] || self.currentTokenIs(token.RBRACE) {
+            return true
+        }
+    }
+
+    depth := 0
+    if self.currentTokenIs(token.LBRACE) {
+        depth += 1
+    }
+
+    for !self.peekTokenIs(token.EOF) {
+        if depth == 0 && (self.peekTokenIs(token.RBRACE) || statementKeywords[self.peekToken.Subtype]) {
+            break
+        }
+        self.nextToken()
+
+        if self.currentTokenIs(token.LBRACE) {
+            depth += 1
+        } else if self.currentTokenIs(token.RBRACE) && depth > 0 {
+            depth -= 1
+        }
+    }
+
+    return false
 }
 func (self *Parser) statementIsTerminated() bool {
-    if (self.peekTokenIs(token.KEYWORD) && !self.peekTokenIs(token.TO)) || self.peekTokenIs(token.EOF) {
+    if (self.peekTokenIs(token.KEYWORD) && !self.peekTokenIs(token.TO) && !self.peekTokenIs(token.IN)) || self.peekTokenIs(token.EOF) {
         return true
     }
     return false
@@ -154,6 +226,14 @@ func (self *Parser) expectPeekTokenToBe(tokenType int ) bool {
     self.addPeekError(tokenType)
     return false
 }
+// Types are either type keywords or the names of user defined types
+func (self *Parser) expectPeekType() bool {
+    if self.peekTokenIs(token.IDENTIFIER) {
+        self.nextToken()
+        return true
+    }
+    return self.expectPeekTokenToBe(token.TYPE)
+}
 func (self *Parser) peekPrecedence() int {
     if precedence, ok := precedences[self.peekToken.Subtype]; ok {
         return precedence
@@ -164,17 +244,59 @@ func (self *Parser) peekPrecedence() int {
 // ======
 // ERRORS
 // ======
-func (self *Parser) addPeekError(tokenType int) {
-    message := fmt.Sprintf("expected next token to be %d, got %s instead", tokenType, self.peekToken.Literal)
-    self.Errors = append(self.Errors, message)
+func (self *Parser) addError(err *ParseError) {
+    // Illegal tokens are reported as soon as they are read
+    if !self.recovering && err.Found.Type != token.ILLEGAL {
+        self.Errors = append(self.Errors, err)
+    }
+    self.recovering = true
+}
+func (self *Parser) addPeekError(tokenTypes ...int) {
+    expected := []string{}
+    for _, tokenType := range tokenTypes {
+        expected = append(expected, token.Name(tokenType))
+    }
+
+    self.addError(&ParseError{
+        Code: UNEXPECTED_TOKEN,
+        Message: fmt.Sprintf("expected %s, found %s", strings.Join(expected, " or "), describe(self.peekToken)),
+        Position: self.peekToken.Position,
+        Expected: expected,
+        Found: self.peekToken,
+    })
+}
+func (self *Parser) addNoPrefixParseFnError(found token.Token) {
+    self.addError(&ParseError{
+        Code: EXPECTED_EXPRESSION,
+        Message: fmt.Sprintf("expected expression, found %s", describe(found)),
+        Position: found.Position,
+        Expected: []string{"expression"},
+        Found: found,
+    })
 }
-func (self *Parser) addNoPrefixParseFnError(token token.Token) {
-    message := fmt.Sprintf("no prefix parse function for %s found", token.Literal)
-    self.Errors = append(self.Errors, message)
+func (self *Parser) addIllegalTokenError(found token.Token) {
+    self.Errors = append(self.Errors, &ParseError{
+        Code: ILLEGAL_TOKEN,
+        Message: found.Literal,
+        Position: found.Position,
+        Found: found,
+    })
 }
-func (self *Parser) addParseError(token token.Token) {
-    message := fmt.Sprintf("could not parse %s token", token.Literal)
-    self.Errors = append(self.Errors, message)
+func (self *Parser) addTypeError(typeLiteral *ast.TypeLiteral, message string) {
+    self.addError(&ParseError{
+        Code: INVALID_TYPE,
+        Message: message,
+        Position: typeLiteral.Position,
+        Found: typeLiteral.Type,
+    })
+}
+func (self *Parser) addNumberError(found token.Token, format string) {
+    self.addError(&ParseError{
+        Code: INVALID_LITERAL,
+        Message: fmt.Sprintf(format, found.Literal),
+        Position: found.Position,
+        Found: found,
+    })
 }
 
 // =======
@@ -190,6 +312,8 @@ func (self *Parser) parseStatement() ast.Statement {
         return self.parseMutStatement()
     case token.EXE:
         return self.parseExeStatement()
+    case token.USE:
+        return self.parseUseStatement()
     case token.BREAK:
         return self.parseBreakStatement()
     case token.CONTINUE:
@@ -204,8 +328,11 @@ func (self *Parser) parseExpression(precedence int) ast.Expression {
         self.addNoPrefixParseFnError(self.currentToken)
         return nil
     }
-    leftExpression := prefixFunction()
-
+    return self.parseInfixExpressions(prefixFunction(), precedence)
+}
+// Extends leftExpression with the infix operators that bind tighter than
+// precedence
+func (self *Parser) parseInfixExpressions(leftExpression ast.Expression, precedence int) ast.Expression {
     for !self.statementIsTerminated() && precedence < self.peekPrecedence() {
         infixFunction, ok := self.infixParseFns[self.peekToken.Subtype]
         if !ok {
@@ -222,18 +349,32 @@ func (self *Parser) parseExpression(precedence int) ast.Expression {
 // STATEMENTS
 // ==========
 func (self *Parser) parseLetStatement() *ast.LetStatement {
-    statement := &ast.LetStatement{}
+    statement := &ast.LetStatement{Position: self.currentToken.Position}
+
+    // `_` can only be used as one of several targets
+    if self.peekTokenIs(token.UNDERSCORE) {
+        self.nextToken()
+        statement.Identifier = self.parseIdentifier().(*ast.Identifier)
+        if !self.peekTokenIs(token.COMMA) {
+            self.addPeekError(token.COMMA)
+            return nil
+        }
+        return self.parseDestructuringLetStatement(statement)
+    }
 
     if !self.expectPeekTokenToBe(token.IDENTIFIER) { return nil }
     statement.Identifier = self.parseIdentifier().(*ast.Identifier)
 
+    if self.peekTokenIs(token.COMMA) {
+        return self.parseDestructuringLetStatement(statement)
+    }
     if self.peekTokenIs(token.BE) {
         return self.parseLetBeStatement(statement)
     }
 
     if !self.expectPeekTokenToBe(token.COLON) { return nil }
 
-    if !self.expectPeekTokenToBe(token.TYPE) { return nil }
+    if !self.expectPeekType() { return nil }
     statement.Identifier.Type = self.parseTypeLiteral()
 
     if !self.expectPeekTokenToBe(token.ASSIGN) { return nil }
@@ -243,74 +384,192 @@ func (self *Parser) parseLetStatement() *ast.LetStatement {
 
     return statement
 }
+// A literal gives its type right away, like i64 in let x be 5, or fn in
+// let f be fn(...). The type of any other expression is left nil, to be
+// inferred by the checker.
 func (self *Parser) parseLetBeStatement(statement *ast.LetStatement) *ast.LetStatement {
     self.nextToken()
+    self.nextToken()
 
-    if !self.peekTokenIs(token.TYPE) && !self.peekTokenIs(token.LITERAL) { 
-        self.addPeekError(token.LITERAL)
+    statement.Expression = self.parseExpression(LOWEST)
+    if statement.Expression == nil { return nil }
+
+    statement.Identifier.Type = literalType(statement.Expression)
+
+    return statement
+}
+// Taken from the node rather than its first token, which may be a
+// parenthesis, like in let x be (5)
+func literalType(expression ast.Expression) *ast.TypeLiteral {
+    var name string
+    switch expression.(type) {
+    case *ast.IntegerLiteral:
+        name = "i64"
+    case *ast.FloatLiteral:
+        name = "f64"
+    case *ast.StringLiteral, *ast.InterpolatedString:
+        name = "str"
+    case *ast.BooleanLiteral:
+        name = "bool"
+    case *ast.FunctionLiteral:
+        name = "fn"
+    case *ast.StructLiteral:
+        name = "struct"
+    case *ast.EnumLiteral:
+        name = "enum"
+    case *ast.InterfaceLiteral:
+        name = "interface"
+    case *ast.ListLiteral:
+        name = "list"
+    case *ast.MapLiteral:
+        name = "map"
+    case *ast.SetLiteral:
+        name = "set"
+    case *ast.TupleLiteral:
+        name = "tuple"
+    case *ast.VecLiteral:
+        name = "vec"
+    default:
         return nil
     }
+
+    return &ast.TypeLiteral{Type: token.NewIdentifier(name), Position: expression.Pos()}
+}
+// let a, b be f()
+func (self *Parser) parseDestructuringLetStatement(statement *ast.LetStatement) *ast.LetStatement {
+    statement.Targets = []*ast.Identifier{statement.Identifier}
+    statement.Identifier = nil
+
+    for self.peekTokenIs(token.COMMA) {
+        self.nextToken()
+        if !self.peekTokenIs(token.IDENTIFIER) && !self.peekTokenIs(token.UNDERSCORE) {
+            self.addPeekError(token.IDENTIFIER, token.UNDERSCORE)
+            return nil
+        }
+        self.nextToken()
+        statement.Targets = append(statement.Targets, self.parseIdentifier().(*ast.Identifier))
+    }
+
+    if !self.expectPeekTokenToBe(token.BE) { return nil }
     self.nextToken()
 
-    // if !self.expectPeekTokenToBe(token.LITERAL) { return nil }
-    statement.Identifier.Type = self.parseTypeLiteral()
-    statement.Expression = self.parseExpression(LOWEST)
+    statement.Expression = self.parseTupleTail(self.parseExpression(LOWEST))
 
     return statement
 }
 func (self *Parser) parseReturnStatement() *ast.ReturnStatement {
-    statement := &ast.ReturnStatement{}
+    statement := &ast.ReturnStatement{Position: self.currentToken.Position}
     self.nextToken()
-    statement.Expression = self.parseExpression(LOWEST)
+    statement.Expression = self.parseTupleTail(self.parseExpression(LOWEST))
 
     return statement
 }
 func (self *Parser) parseExpressionStatement() *ast.ExpressionStatement {
-    statement := &ast.ExpressionStatement{}
+    statement := &ast.ExpressionStatement{Position: self.currentToken.Position}
     statement.Expression = self.parseExpression(LOWEST)
 
     return statement
 }
 func (self *Parser) parseMutStatement() *ast.MutStatement {
-    statement := &ast.MutStatement{}
+    statement := &ast.MutStatement{Position: self.currentToken.Position}
 
-    if !self.expectPeekTokenToBe(token.IDENTIFIER) { return nil }
-    statement.Identifier = self.parseIdentifier()
+    statement.Identifier = self.parseMutTarget()
+    if statement.Identifier == nil { return nil }
 
-    for self.peekTokenIs(token.LPAREN) {
-        self.nextToken()
-        statement.Identifier = self.parseCallExpression(statement.Identifier)
+    // Several targets, like in mut a, b to b, a
+    if self.peekTokenIs(token.COMMA) {
+        targets := &ast.TupleLiteral{Elements: []ast.Expression{statement.Identifier}, Position: statement.Identifier.Pos()}
+        for self.peekTokenIs(token.COMMA) {
+            self.nextToken()
+            target := self.parseMutTarget()
+            if target == nil { return nil }
+            targets.Elements = append(targets.Elements, target)
+        }
+        statement.Identifier = targets
     }
 
     if !self.expectPeekTokenToBe(token.TO) { return nil }
 
-    if self.peekTokenIs(token.OPERATOR) || self.peekTokenIs(token.DELIMITER) {
-        if ident, ok := statement.Identifier.(*ast.Identifier); ok {
-            self.currentToken = token.NewIdentifier(ident.Name)
-        }
-    } else {
+    // Shorthand, like in mut x to + 1, where the target is the left operand
+    _, multiple := statement.Identifier.(*ast.TupleLiteral)
+    if !multiple && (self.peekTokenIs(token.OPERATOR) || self.peekTokenIs(token.DELIMITER)) {
+        statement.Expression = self.parseInfixExpressions(statement.Identifier, LOWEST)
+        return statement
+    }
+    self.nextToken()
+
+    statement.Expression = self.parseTupleTail(self.parseExpression(LOWEST))
+
+    return statement
+}
+// A name, optionally followed by indices and fields, like xs(0) or p.x
+func (self *Parser) parseMutTarget() ast.Expression {
+    if self.peekTokenIs(token.SELF) {
         self.nextToken()
+    } else if !self.expectPeekTokenToBe(token.IDENTIFIER) {
+        return nil
     }
+    target := self.parseIdentifier()
 
-    statement.Expression = self.parseExpression(LOWEST)
+    for self.peekTokenIs(token.LPAREN) || self.peekTokenIs(token.DOT) {
+        self.nextToken()
+        if self.currentTokenIs(token.LPAREN) {
+            target = self.parseCallExpression(target)
+        } else {
+            target = self.parseFieldExpression(target)
+        }
+        if target == nil { return nil }
+    }
+
+    return target
+}
+func (self *Parser) parseUseStatement() ast.Statement {
+    statement := &ast.UseStatement{Position: self.currentToken.Position}
+
+    if !self.peekTokenIs(token.STR) || self.peekToken.Type != token.LITERAL {
+        self.addError(&ParseError{
+            Code: UNEXPECTED_TOKEN,
+            Message: fmt.Sprintf("expected module path, found %s", describe(self.peekToken)),
+            Position: self.peekToken.Position,
+            Expected: []string{"module path"},
+            Found: self.peekToken,
+        })
+        return nil
+    }
+    self.nextToken()
 
+    statement.Path = self.currentToken.Literal
+    statement.Name = strings.TrimSuffix(path.Base(statement.Path), ".kimchi")
     return statement
 }
 func (self *Parser) parseExeStatement() *ast.ExeStatement {
-    statement := &ast.ExeStatement{}
+    statement := &ast.ExeStatement{Position: self.currentToken.Position}
 
-    if !self.expectPeekTokenToBe(token.IDENTIFIER) { return nil }
-    statement.Function = self.parseIdentifier().(*ast.Identifier)
+    if self.peekTokenIs(token.SELF) {
+        self.nextToken()
+    } else if !self.expectPeekTokenToBe(token.IDENTIFIER) {
+        return nil
+    }
+    statement.Function = self.parseIdentifier()
+
+    // Methods take their arguments in the dot expression, like exe p.move(1)
+    if self.peekTokenIs(token.DOT) {
+        for self.peekTokenIs(token.DOT) {
+            self.nextToken()
+            statement.Function = self.parseDotExpression(statement.Function)
+        }
+        return statement
+    }
 
     if self.peekTokenIs(token.LPAREN) {
         self.nextToken()
-        statement.Arguments = self.parseExpressionList()
+        statement.Arguments = self.parseCallArguments()
     }
 
     return statement
 }
 func (self *Parser) parseBreakStatement() ast.Statement {
-    statement := &ast.BreakStatement{}
+    statement := &ast.BreakStatement{Position: self.currentToken.Position}
 
     if self.peekTokenIs(token.IF) {
         self.nextToken()
@@ -322,7 +581,7 @@ func (self *Parser) parseBreakStatement() ast.Statement {
     return statement
 }
 func (self *Parser) parseContinueStatement() ast.Statement {
-    statement := &ast.ContinueStatement{}
+    statement := &ast.ContinueStatement{Position: self.currentToken.Position}
     
     if self.peekTokenIs(token.IF) {
         self.nextToken()
@@ -340,6 +599,7 @@ func (self *Parser) parseContinueStatement() ast.Statement {
 func (self *Parser) parsePrefixExpression() ast.Expression {
     expression := &ast.PrefixExpression{
         Operator: self.currentToken.Literal,
+        Position: self.currentToken.Position,
     }
 
     self.nextToken()
@@ -349,13 +609,16 @@ func (self *Parser) parsePrefixExpression() ast.Expression {
 }
 func (self *Parser) parseInfixExpression(leftExpression ast.Expression) ast.Expression {
     if self.currentTokenIs(token.IS) && self.peekTokenIs(token.NOT) {
+        position := self.currentToken.Position
         self.nextToken()
         self.currentToken = token.NewIdentifier("is_not")
+        self.currentToken.Position = position
     }
 
     expression := &ast.InfixExpression{
         Operator: self.currentToken.Literal,
         Left: leftExpression,
+        Position: self.currentToken.Position,
     }
 
     precedende := LOWEST
@@ -378,7 +641,7 @@ func (self *Parser) parseGroupedExpression() ast.Expression {
     return expression
 }
 func (self *Parser) parseBlockStatement() *ast.BlockStatement {
-    block := &ast.BlockStatement{Statements: []ast.Statement{}}
+    block := &ast.BlockStatement{Statements: []ast.Statement{}, Position: self.currentToken.Position}
     self.nextToken()
 
     for !self.currentTokenIs(token.RBRACE) && !self.currentTokenIs(token.EOF) {
@@ -386,11 +649,30 @@ func (self *Parser) parseBlockStatement() *ast.BlockStatement {
         if statement != nil {
             block.Statements = append(block.Statements, statement)
         }
+        if self.recovering && self.synchronize() {
+            continue
+        }
         self.nextToken()
     }
 
     return block
 }
+// Collects a comma separated list of expressions into a tuple, like in
+// return a, b
+func (self *Parser) parseTupleTail(first ast.Expression) ast.Expression {
+    if first == nil || !self.peekTokenIs(token.COMMA) {
+        return first
+    }
+
+    tuple := &ast.TupleLiteral{Elements: []ast.Expression{first}, Position: first.Pos()}
+    for self.peekTokenIs(token.COMMA) {
+        self.nextToken()
+        self.nextToken()
+        tuple.Elements = append(tuple.Elements, self.parseExpression(LOWEST))
+    }
+
+    return tuple
+}
 func (self *Parser) parseExpressionList() []ast.Expression {
     list := []ast.Expression{}
 
@@ -417,7 +699,7 @@ func (self *Parser) parseExpressionList() []ast.Expression {
 // KEYWORDS
 // ========
 func (self *Parser) parseIfExpression() ast.Expression {
-    expression := &ast.IfExpression{}
+    expression := &ast.IfExpression{Position: self.currentToken.Position}
     self.nextToken()
 
     expression.Condition = self.parseExpression(LOWEST)
@@ -431,7 +713,9 @@ func (self *Parser) parseIfExpression() ast.Expression {
 
         if self.peekTokenIs(token.IF) {
             self.nextToken()
-            expression.Alternative = &ast.BlockStatement{Statements: []ast.Statement{&ast.ExpressionStatement{Expression: self.parseIfExpression()}}}
+            position := self.currentToken.Position
+            alternative := &ast.ExpressionStatement{Expression: self.parseIfExpression(), Position: position}
+            expression.Alternative = &ast.BlockStatement{Statements: []ast.Statement{alternative}, Position: position}
 
             return expression
         }
@@ -442,8 +726,81 @@ func (self *Parser) parseIfExpression() ast.Expression {
 
     return expression
 }
+func (self *Parser) parseMatchExpression() ast.Expression {
+    expression := &ast.MatchExpression{Position: self.currentToken.Position}
+    self.nextToken()
+
+    expression.Subject = self.parseExpression(LOWEST)
+
+    if !self.expectPeekTokenToBe(token.LBRACE) { return nil }
+
+    for !self.peekTokenIs(token.RBRACE) {
+        if self.peekTokenIs(token.EOF) {
+            self.addPeekError(token.RBRACE)
+            return nil
+        }
+        self.nextToken()
+
+        arm := self.parseMatchArm()
+        if arm == nil { return nil }
+        expression.Arms = append(expression.Arms, arm)
+
+        // Arms can optionally be separated by commas
+        if self.peekTokenIs(token.COMMA) {
+            self.nextToken()
+        }
+    }
+    self.nextToken()
+
+    return expression
+}
+func (self *Parser) parseMatchArm() *ast.MatchArm {
+    arm := &ast.MatchArm{Position: self.currentToken.Position}
+
+    arm.Pattern = self.parseExpression(LOWEST)
+    if arm.Pattern == nil { return nil }
+
+    if self.peekTokenIs(token.IF) {
+        self.nextToken()
+        self.nextToken()
+        arm.Guard = self.parseExpression(LOWEST)
+    }
+
+    if !self.expectPeekTokenToBe(token.COLON) { return nil }
+
+    if self.peekTokenIs(token.LBRACE) {
+        self.nextToken()
+        arm.Body = self.parseBlockStatement()
+        return arm
+    }
+
+    self.nextToken()
+    position := self.currentToken.Position
+    body := &ast.ExpressionStatement{Expression: self.parseExpression(LOWEST), Position: position}
+    arm.Body = &ast.BlockStatement{Statements: []ast.Statement{body}, Position: position}
+
+    return arm
+}
+func (self *Parser) parseRestPattern() ast.Expression {
+    pattern := &ast.RestPattern{Position: self.currentToken.Position}
+
+    if !self.peekTokenIs(token.IDENTIFIER) && !self.peekTokenIs(token.UNDERSCORE) {
+        self.addPeekError(token.IDENTIFIER, token.UNDERSCORE)
+        return nil
+    }
+    self.nextToken()
+    pattern.Identifier = self.parseIdentifier().(*ast.Identifier)
+
+    return pattern
+}
 func (self *Parser) parseFunctionLiteral() ast.Expression {
-    literal := &ast.FunctionLiteral{}
+    literal := &ast.FunctionLiteral{Position: self.currentToken.Position}
+
+    if self.peekTokenIs(token.LBRACKET) {
+        self.nextToken()
+        literal.TypeParameters = self.parseTypeParameters()
+        if literal.TypeParameters == nil { return nil }
+    }
 
     if !self.expectPeekTokenToBe(token.LPAREN) { return nil }
 
@@ -451,7 +808,7 @@ func (self *Parser) parseFunctionLiteral() ast.Expression {
 
     if !self.expectPeekTokenToBe(token.COLON) { return nil }
 
-    if !self.expectPeekTokenToBe(token.TYPE) { return nil }
+    if !self.expectPeekType() { return nil }
     literal.ReturnType = self.parseTypeLiteral()
 
     if !self.expectPeekTokenToBe(token.LBRACE) { return nil }
@@ -460,6 +817,35 @@ func (self *Parser) parseFunctionLiteral() ast.Expression {
 
     return literal
 }
+// Type parameters are the names in brackets of generic functions, like
+// fn[K, V](m: map(K, V)): list(K)
+func (self *Parser) parseTypeParameters() []*ast.Identifier {
+    identifiers := []*ast.Identifier{}
+    seen := map[string]bool{}
+
+    for {
+        if !self.expectPeekTokenToBe(token.IDENTIFIER) { return nil }
+        identifier := &ast.Identifier{Name: self.currentToken.Literal, Position: self.currentToken.Position}
+        if seen[identifier.Name] {
+            self.addError(&ParseError{
+                Code: INVALID_TYPE,
+                Message: "duplicate type parameter " + identifier.Name,
+                Position: identifier.Position,
+                Found: self.currentToken,
+            })
+            return nil
+        }
+        seen[identifier.Name] = true
+        identifiers = append(identifiers, identifier)
+
+        if !self.peekTokenIs(token.COMMA) { break }
+        self.nextToken()
+    }
+
+    if !self.expectPeekTokenToBe(token.RBRACKET) { return nil }
+
+    return identifiers
+}
 func (self *Parser) parseFunctionParameters() []*ast.Identifier {
     identifiers := []*ast.Identifier{}
 
@@ -469,23 +855,24 @@ func (self *Parser) parseFunctionParameters() []*ast.Identifier {
     }
 
     self.nextToken()
-    identifier := &ast.Identifier{Name: self.currentToken.Literal}
-
-    if !self.expectPeekTokenToBe(token.COLON) { return nil }
-
-    if !self.expectPeekTokenToBe(token.TYPE) { return nil }
-    identifier.Type = self.parseTypeLiteral()
+    identifier := self.parseFunctionParameter()
+    if identifier == nil { return nil }
     identifiers = append(identifiers, identifier)
 
     for self.peekTokenIs(token.COMMA) {
+        if identifier.Variadic {
+            self.addError(&ParseError{
+                Code: UNEXPECTED_TOKEN,
+                Message: "variadic parameter " + identifier.Name + " must be the last one",
+                Position: identifier.Position,
+                Found: self.peekToken,
+            })
+            return nil
+        }
         self.nextToken()
         self.nextToken()
-        identifier := &ast.Identifier{Name: self.currentToken.Literal}
-        
-        if !self.expectPeekTokenToBe(token.COLON) { return nil }
-
-        if !self.expectPeekTokenToBe(token.TYPE) { return nil }
-        identifier.Type = self.parseTypeLiteral()
+        identifier = self.parseFunctionParameter()
+        if identifier == nil { return nil }
         identifiers = append(identifiers, identifier)
     }
 
@@ -493,62 +880,188 @@ func (self *Parser) parseFunctionParameters() []*ast.Identifier {
 
     return identifiers
 }
+// A parameter can be variadic, like args: ...i64, or have a default
+// value, like n: i64 = 10
+func (self *Parser) parseFunctionParameter() *ast.Identifier {
+    identifier := &ast.Identifier{Name: self.currentToken.Literal, Position: self.currentToken.Position}
+
+    if !self.expectPeekTokenToBe(token.COLON) { return nil }
+
+    if self.peekTokenIs(token.ELLIPSIS) {
+        self.nextToken()
+        identifier.Variadic = true
+    }
+
+    if !self.expectPeekType() { return nil }
+    identifier.Type = self.parseTypeLiteral()
+
+    if self.peekTokenIs(token.ASSIGN) {
+        self.nextToken()
+        self.nextToken()
+        identifier.Default = self.parseExpression(LOWEST)
+    }
+
+    return identifier
+}
 func (self *Parser) parseCallExpression(function ast.Expression) ast.Expression {
-    expression := &ast.CallExpression{Function: function}
-    expression.Arguments = self.parseExpressionList()
+    expression := &ast.CallExpression{Function: function, Position: self.currentToken.Position}
+    expression.Arguments = self.parseCallArguments()
 
     return expression
 }
+// Like parseExpressionList, but arguments can be named: f(1, n: 2)
+func (self *Parser) parseCallArguments() []ast.Expression {
+    arguments := []ast.Expression{}
+
+    if self.peekTokenIs(token.RPAREN) {
+        self.nextToken()
+        return arguments
+    }
+
+    self.nextToken()
+    arguments = append(arguments, self.parseCallArgument())
+
+    for self.peekTokenIs(token.COMMA) {
+        self.nextToken()
+        self.nextToken()
+        arguments = append(arguments, self.parseCallArgument())
+    }
+
+    if !self.expectPeekTokenToBe(token.RPAREN) { return nil }
+
+    return arguments
+}
+func (self *Parser) parseCallArgument() ast.Expression {
+    if !self.currentTokenIs(token.IDENTIFIER) || !self.peekTokenIs(token.COLON) {
+        return self.parseExpression(LOWEST)
+    }
+
+    argument := &ast.NamedArgument{Name: self.parseIdentifier().(*ast.Identifier), Position: self.currentToken.Position}
+    self.nextToken()
+    self.nextToken()
+    argument.Value = self.parseExpression(LOWEST)
+
+    return argument
+}
+// Arguments is left nil when there are no parentheses, like in p.x
 func (self *Parser) parseDotExpression(leftExpression ast.Expression) ast.Expression {
-    expression := &ast.DotExpression{Left: leftExpression, Arguments: []ast.Expression{}}
+    expression := &ast.DotExpression{Left: leftExpression}
     self.nextToken()
     expression.Method = self.parseIdentifier()
+    expression.Position = expression.Method.Pos()
 
     if self.peekTokenIs(token.LPAREN) {
         self.nextToken()
-        expression.Arguments = self.parseExpressionList()
+        expression.Arguments = self.parseCallArguments()
     }
 
     return expression
 }
+// A field without arguments, like the target of mut p.x to 3
+func (self *Parser) parseFieldExpression(leftExpression ast.Expression) ast.Expression {
+    if !self.expectPeekTokenToBe(token.IDENTIFIER) { return nil }
+    field := self.parseIdentifier()
+
+    return &ast.DotExpression{Left: leftExpression, Method: field, Position: field.Pos()}
+}
 
 // ========
 // LITERALS
 // ========
 func (self *Parser) parseIdentifier() ast.Expression {
-    return &ast.Identifier{Name: self.currentToken.Literal}
+    return &ast.Identifier{Name: self.currentToken.Literal, Position: self.currentToken.Position}
 }
 func (self *Parser) parseTypeLiteral() *ast.TypeLiteral {
-    if self.currentTokenIs(token.LITERAL) {
-        return &ast.TypeLiteral{Type: token.NewFromType(self.currentToken.Subtype)}
+    typeLiteral := &ast.TypeLiteral{Type: self.currentToken, Position: self.currentToken.Position}
+
+    // Collections and functions can be used without their element types,
+    // like in let x: list = ...
+    if !self.peekTokenIs(token.LPAREN) {
+        return typeLiteral
     }
-    typeLiteral := &ast.TypeLiteral{Type: self.currentToken}
 
     switch self.currentToken.Subtype {
-    case token.LIST:
-        if !self.expectPeekTokenToBe(token.LPAREN) { return nil }
-        if !self.expectPeekTokenToBe(token.TYPE) { return nil }
-        typeLiteral.Subtypes = append(typeLiteral.Subtypes, self.currentToken)
-        if !self.expectPeekTokenToBe(token.RPAREN) { return nil }
+    case token.LIST, token.SET, token.VEC:
+        typeLiteral.Subtypes = self.parseTypeList()
+        if typeLiteral.Subtypes == nil || !self.checkTypeArity(typeLiteral, 1) { return nil }
 
     case token.MAP:
-        if !self.expectPeekTokenToBe(token.LPAREN) { return nil }
-        if !self.expectPeekTokenToBe(token.TYPE) { return nil }
-        typeLiteral.Subtypes = append(typeLiteral.Subtypes, self.currentToken)
-        if !self.expectPeekTokenToBe(token.COMMA) { return nil }
-        if !self.expectPeekTokenToBe(token.TYPE) { return nil }
-        typeLiteral.Subtypes = append(typeLiteral.Subtypes, self.currentToken)
-        if !self.expectPeekTokenToBe(token.RPAREN) { return nil }
+        typeLiteral.Subtypes = self.parseTypeList()
+        if typeLiteral.Subtypes == nil || !self.checkTypeArity(typeLiteral, 2) { return nil }
+
+    case token.TUPLE:
+        typeLiteral.Subtypes = self.parseTypeList()
+        if typeLiteral.Subtypes == nil { return nil }
+        if len(typeLiteral.Subtypes) == 0 {
+            self.addTypeError(typeLiteral, "tuple type needs at least one element type")
+            return nil
+        }
+
+    case token.FN:
+        typeLiteral.Subtypes = self.parseTypeList()
+        if typeLiteral.Subtypes == nil { return nil }
+
+        if !self.expectPeekTokenToBe(token.COLON) { return nil }
+        if !self.expectPeekType() { return nil }
+        typeLiteral.Return = self.parseTypeLiteral()
+        if typeLiteral.Return == nil { return nil }
     }
 
     return typeLiteral
 }
+// Parses a parenthesized and comma separated list of types
+func (self *Parser) parseTypeList() []*ast.TypeLiteral {
+    types := []*ast.TypeLiteral{}
+
+    if !self.expectPeekTokenToBe(token.LPAREN) { return nil }
+    if self.peekTokenIs(token.RPAREN) {
+        self.nextToken()
+        return types
+    }
+
+    for {
+        if !self.expectPeekType() { return nil }
+        subtype := self.parseTypeLiteral()
+        if subtype == nil { return nil }
+        types = append(types, subtype)
+
+        if !self.peekTokenIs(token.COMMA) {
+            break
+        }
+        self.nextToken()
+    }
+
+    if !self.expectPeekTokenToBe(token.RPAREN) { return nil }
+
+    return types
+}
+func (self *Parser) checkTypeArity(typeLiteral *ast.TypeLiteral, arity int) bool {
+    if len(typeLiteral.Subtypes) == arity {
+        return true
+    }
+
+    noun := "types"
+    if arity == 1 {
+        noun = "type"
+    }
+    self.addTypeError(typeLiteral, fmt.Sprintf("%s type takes %d element %s, got %d", typeLiteral.Type.Literal, arity, noun, len(typeLiteral.Subtypes)))
+    return false
+}
 func (self *Parser) parseIntegerLiteral() ast.Expression {
-    literal := &ast.IntegerLiteral{}
+    literal := &ast.IntegerLiteral{Position: self.currentToken.Position}
 
-    value, err := strconv.ParseInt(self.currentToken.Literal, 10, 64)
+    digits, base, ok := splitNumber(self.currentToken.Literal)
+    if !ok {
+        self.addNumberError(self.currentToken, "invalid integer literal %s")
+        return nil
+    }
+    value, err := strconv.ParseInt(digits, base, 64)
+    if errors.Is(err, strconv.ErrRange) {
+        self.addNumberError(self.currentToken, "integer literal %s is out of range for i64")
+        return nil
+    }
     if err != nil {
-        self.addParseError(self.currentToken)
+        self.addNumberError(self.currentToken, "invalid integer literal %s")
         return nil
     }
 
@@ -556,11 +1069,20 @@ func (self *Parser) parseIntegerLiteral() ast.Expression {
     return literal
 }
 func (self *Parser) parseFloatLiteral() ast.Expression {
-    literal := &ast.FloatLiteral{}
+    literal := &ast.FloatLiteral{Position: self.currentToken.Position}
 
-    value, err := strconv.ParseFloat(self.currentToken.Literal, 64)
+    digits, _, ok := splitNumber(self.currentToken.Literal)
+    if !ok {
+        self.addNumberError(self.currentToken, "invalid float literal %s")
+        return nil
+    }
+    value, err := strconv.ParseFloat(digits, 64)
+    if errors.Is(err, strconv.ErrRange) {
+        self.addNumberError(self.currentToken, "float literal %s is out of range for f64")
+        return nil
+    }
     if err != nil {
-        self.addParseError(self.currentToken)
+        self.addNumberError(self.currentToken, "invalid float literal %s")
         return nil
     }
 
@@ -568,29 +1090,111 @@ func (self *Parser) parseFloatLiteral() ast.Expression {
     return literal
 }
 func (self *Parser) parseStringLiteral() ast.Expression {
-    return &ast.StringLiteral{Value: self.currentToken.Literal}
+    return &ast.StringLiteral{Value: self.currentToken.Literal, Position: self.currentToken.Position}
+}
+func (self *Parser) parseInterpolatedString() ast.Expression {
+    expression := &ast.InterpolatedString{Position: self.currentToken.Position}
+    expression.Parts = self.appendStringPart(expression.Parts)
+
+    for {
+        self.nextToken()
+        part := self.parseExpression(LOWEST)
+        if part == nil { return nil }
+        expression.Parts = append(expression.Parts, part)
+
+        if self.peekTokenIs(token.TEMPLATE_MIDDLE) {
+            self.nextToken()
+            expression.Parts = self.appendStringPart(expression.Parts)
+            continue
+        }
+
+        if !self.expectPeekTokenToBe(token.TEMPLATE_TAIL) { return nil }
+        expression.Parts = self.appendStringPart(expression.Parts)
+
+        return expression
+    }
+}
+func (self *Parser) appendStringPart(parts []ast.Expression) []ast.Expression {
+    if self.currentToken.Literal == "" {
+        return parts
+    }
+    return append(parts, self.parseStringLiteral())
 }
 func (self *Parser) parseBooleanLiteral() ast.Expression {
-    return &ast.BooleanLiteral{Value: self.currentTokenIs(token.TRUE)}
+    return &ast.BooleanLiteral{Value: self.currentTokenIs(token.TRUE), Position: self.currentToken.Position}
+}
+
+// Strips the digit separators and the base prefix of a number literal.
+// Separators are only allowed between two digits.
+func splitNumber(literal string) (string, int, bool) {
+    base := 10
+    if len(literal) > 1 && literal[0] == '0' {
+        switch literal[1] {
+        case 'x', 'X':
+            base = 16
+        case 'b', 'B':
+            base = 2
+        case 'o', 'O':
+            base = 8
+        }
+        if base != 10 {
+            literal = literal[2:]
+        }
+    }
+
+    isDigit := func(char byte) bool {
+        return ('0' <= char && char <= '9') || (base == 16 && strings.IndexByte("abcdefABCDEF", char) >= 0)
+    }
+    for i := 0; i < len(literal); i++ {
+        if literal[i] == '_' && (i == 0 || i == len(literal)-1 || !isDigit(literal[i-1]) || !isDigit(literal[i+1])) {
+            return "", base, false
+        }
+    }
+
+    return strings.ReplaceAll(literal, "_", ""), base, true
 }
 
 // ======
 // ARRAYS
 // ======
 func (self *Parser) parseListLiteral() ast.Expression {
-    list := &ast.ListLiteral{}
+    list := &ast.ListLiteral{Position: self.currentToken.Position}
 
     if !self.expectPeekTokenToBe(token.LPAREN) { return nil }
 
     list.Elements = self.parseExpressionList()
     return list
 }
+func (self *Parser) parseTupleLiteral() ast.Expression {
+    tuple := &ast.TupleLiteral{Position: self.currentToken.Position}
+
+    if !self.expectPeekTokenToBe(token.LPAREN) { return nil }
+
+    tuple.Elements = self.parseExpressionList()
+    return tuple
+}
+func (self *Parser) parseVecLiteral() ast.Expression {
+    vec := &ast.VecLiteral{Position: self.currentToken.Position}
+
+    if !self.expectPeekTokenToBe(token.LPAREN) { return nil }
+
+    vec.Elements = self.parseExpressionList()
+    return vec
+}
 
 // ===========
 // COLLECTIONS
 // ===========
+func (self *Parser) parseSetLiteral() ast.Expression {
+    set := &ast.SetLiteral{Position: self.currentToken.Position}
+
+    if !self.expectPeekTokenToBe(token.LPAREN) { return nil }
+
+    set.Elements = self.parseExpressionList()
+    return set
+}
 func (self *Parser) parseMapLiteral() ast.Expression {
-    mapLiteral := &ast.MapLiteral{Pairs: make(map[ast.Expression]ast.Expression)}
+    mapLiteral := &ast.MapLiteral{Pairs: []*ast.MapPair{}, Position: self.currentToken.Position}
     self.nextToken()
 
     for !self.peekTokenIs(token.RPAREN) {
@@ -601,7 +1205,7 @@ func (self *Parser) parseMapLiteral() ast.Expression {
         self.nextToken()
 
         value := self.parseExpression(LOWEST)
-        mapLiteral.Pairs[key] = value
+        mapLiteral.Pairs = append(mapLiteral.Pairs, &ast.MapPair{Key: key, Value: value})
 
         if !self.peekTokenIs(token.RPAREN) && !self.expectPeekTokenToBe(token.COMMA) { return nil }
     }
@@ -611,20 +1215,101 @@ func (self *Parser) parseMapLiteral() ast.Expression {
     return mapLiteral
 }
 func (self *Parser) parseStructLiteral() ast.Expression {
-    literal := &ast.StructLiteral{Fields: []*ast.Identifier{}}
+    literal := &ast.StructLiteral{Fields: []*ast.Identifier{}, Position: self.currentToken.Position}
 
     if !self.expectPeekTokenToBe(token.LPAREN) { return nil }
     literal.Fields = self.parseFunctionParameters()
 
+    // Implemented interfaces, like struct(r: f64) is Shape, Named
+    if self.peekTokenIs(token.IS) {
+        self.nextToken()
+        for {
+            if !self.expectPeekTokenToBe(token.IDENTIFIER) { return nil }
+            literal.Interfaces = append(literal.Interfaces, self.parseIdentifier().(*ast.Identifier))
+            if !self.peekTokenIs(token.COMMA) { break }
+            self.nextToken()
+        }
+    }
+
+    if self.peekTokenIs(token.LBRACE) {
+        self.nextToken()
+        literal.Methods = self.parseStructMethods()
+        if literal.Methods == nil { return nil }
+    }
+
+    return literal
+}
+// Methods are declared with let in a block after the fields:
+// struct(x: i64) { let double be fn(): i64 { return self.x * 2 } }
+func (self *Parser) parseStructMethods() []*ast.LetStatement {
+    methods := []*ast.LetStatement{}
+
+    for !self.peekTokenIs(token.RBRACE) {
+        if !self.expectPeekTokenToBe(token.LET) { return nil }
+        method := self.parseLetStatement()
+        if method == nil { return nil }
+
+        if _, ok := method.Expression.(*ast.FunctionLiteral); !ok || method.Identifier == nil {
+            self.addError(&ParseError{
+                Code: INVALID_METHOD,
+                Message: "struct methods must be functions, like let name be fn(...)",
+                Position: method.Position,
+                Found: self.currentToken,
+            })
+            return nil
+        }
+        methods = append(methods, method)
+    }
+    self.nextToken()
+
+    return methods
+}
+
+func (self *Parser) parseInterfaceLiteral() ast.Expression {
+    literal := &ast.InterfaceLiteral{Position: self.currentToken.Position}
+
+    if !self.expectPeekTokenToBe(token.LPAREN) { return nil }
+    literal.Methods = self.parseFunctionParameters()
+    if literal.Methods == nil { return nil }
+
+    for _, method := range literal.Methods {
+        if method.Type.Type.Subtype != token.FN {
+            self.addTypeError(method.Type, "interface methods must have function types, got " + method.Type.String())
+            return nil
+        }
+    }
+
     return literal
 }
 
+func (self *Parser) parseEnumLiteral() ast.Expression {
+    literal := &ast.EnumLiteral{Position: self.currentToken.Position}
+
+    if !self.expectPeekTokenToBe(token.LPAREN) { return nil }
+
+    for !self.peekTokenIs(token.RPAREN) {
+        if !self.expectPeekTokenToBe(token.IDENTIFIER) { return nil }
+        variant := &ast.EnumVariant{Name: self.currentToken.Literal, Position: self.currentToken.Position}
+
+        if self.peekTokenIs(token.LPAREN) {
+            self.nextToken()
+            variant.Fields = self.parseFunctionParameters()
+            if variant.Fields == nil { return nil }
+        }
+        literal.Variants = append(literal.Variants, variant)
+
+        if !self.peekTokenIs(token.RPAREN) && !self.expectPeekTokenToBe(token.COMMA) { return nil }
+    }
+    self.nextToken()
+
+    return literal
+}
 
 // =====
 // LOOPS
 // =====
 func (self *Parser) parseWhileExpression() ast.Expression {
-    expression := &ast.WhileExpression{}
+    expression := &ast.WhileExpression{Position: self.currentToken.Position}
     self.nextToken()
 
     expression.Condition = self.parseExpression(LOWEST)
@@ -636,10 +1321,10 @@ func (self *Parser) parseWhileExpression() ast.Expression {
     return expression
 }
 func (self *Parser) parseForExpression() ast.Expression {
-    expression := &ast.ForExpression{}
+    expression := &ast.ForExpression{Position: self.currentToken.Position}
 
     if !self.peekTokenIs(token.IDENTIFIER) && !self.peekTokenIs(token.UNDERSCORE) { 
-        self.addPeekError(self.peekToken.Subtype)
+        self.addPeekError(token.IDENTIFIER, token.UNDERSCORE)
         return nil 
     }
     self.nextToken()
@@ -648,7 +1333,7 @@ func (self *Parser) parseForExpression() ast.Expression {
     if !self.expectPeekTokenToBe(token.COMMA) { return nil } 
 
     if !self.peekTokenIs(token.IDENTIFIER) && !self.peekTokenIs(token.UNDERSCORE) { 
-        self.addPeekError(self.peekToken.Subtype)
+        self.addPeekError(token.IDENTIFIER, token.UNDERSCORE)
         return nil 
     }
     self.nextToken()
@@ -3,6 +3,7 @@ package parser
 import (
 	"kimchi/ast"
 	"kimchi/tokenizer"
+	"strings"
 	"testing"
 )
 
@@ -59,6 +60,49 @@ func TestLetStatement(t *testing.T) {
     }
 }
 
+func TestLetBeExpression(t *testing.T) {
+    tests := []struct {
+        input string
+        expectedType string
+        expectedExpression string
+    }{
+        {"let total be a + b", "", "(a + b)"},
+        {"let s be x", "", "x"},
+        {"let r be f(1)", "", "f(1)"},
+        {"let y be -5", "", "(-5)"},
+        {"let xs be list(1, 2)", "list", "list(1, 2)"},
+        {"let big be 5 > 3", "", "(5 > 3)"},
+        {"let x be (5)", "i64", "5"},
+        {"let xs be (list(1, 2))", "list", "list(1, 2)"},
+    }
+
+    for _, tt := range tests {
+        parser := New(tokenizer.New(tt.input))
+        program := parser.ParseProgram()
+        checkParserErrors(t, parser)
+
+        if len(program.Statements) != 1 {
+            t.Fatalf("program.Statements does not contain one statement. got=%d", len(program.Statements))
+        }
+        statement, ok := program.Statements[0].(*ast.LetStatement)
+        if !ok {
+            t.Fatalf("program.Statements[0] is not ast.LetStatement. got=%T", program.Statements[0])
+        }
+
+        // Only literals give a type, the rest is inferred by the checker
+        typeName := ""
+        if statement.Identifier.Type != nil {
+            typeName = statement.Identifier.Type.Type.Literal
+        }
+        if typeName != tt.expectedType {
+            t.Errorf("statement.Identifier.Type not %q. got=%q", tt.expectedType, typeName)
+        }
+        if statement.Expression.String() != tt.expectedExpression {
+            t.Errorf("statement.Expression not %q. got=%q", tt.expectedExpression, statement.Expression.String())
+        }
+    }
+}
+
 func TestReturnStatement(t *testing.T) {
     tests := []struct {
         input string
@@ -534,13 +578,18 @@ func TestMapLiteralStringKeys(t *testing.T) {
         t.Fatalf("mapLiteral.Pairs has wrong length. got=%d", len(mapLiteral.Pairs))
     }
 
+    if mapLiteral.String() != "map(one: 1, two: 2, three: 3)" {
+        t.Errorf("mapLiteral.String() is not in source order. got=%s", mapLiteral.String())
+    }
+
     expected := map[string]int64{
         "one":   1,
         "two":   2,
         "three": 3,
     }
 
-    for key, value := range mapLiteral.Pairs {
+    for _, pair := range mapLiteral.Pairs {
+        key, value := pair.Key, pair.Value
         literal, ok := key.(*ast.StringLiteral)
         if !ok {
             t.Errorf("key is not ast.StringLiteral. got=%T", key)
@@ -585,7 +634,8 @@ func TestMapLiteralIntegerKeys(t *testing.T) {
         3: "three",
     }
 
-    for key, value := range mapLiteral.Pairs {
+    for _, pair := range mapLiteral.Pairs {
+        key, value := pair.Key, pair.Value
         literal, ok := key.(*ast.IntegerLiteral)
         if !ok {
             t.Errorf("key is not ast.IntegerLiteral. got=%T", key)
@@ -629,7 +679,8 @@ func TestMapLiteralBooleanKeys(t *testing.T) {
         false: "two",
     }
 
-    for key, value := range mapLiteral.Pairs {
+    for _, pair := range mapLiteral.Pairs {
+        key, value := pair.Key, pair.Value
         literal, ok := key.(*ast.BooleanLiteral)
         if !ok {
             t.Errorf("key is not ast.Boolean. got=%T", key)
@@ -681,6 +732,10 @@ func TestTypeLiteral(t *testing.T) {
         {"let a: str = \"hello\"", "str", nil},
         {"let b: list(i64) = list(1, 2, 3)", "list", []string{"i64"}},
         {"let c: map(i64, str) = map(1: \"one\", 2: \"two\")", "map", []string{"i64", "str"}},
+        {"let d: list = list(1, 2, 3)", "list", nil},
+        {"let e: set(str) = x", "set", []string{"str"}},
+        {"let f: tuple(i64, str, bool) = x", "tuple", []string{"i64", "str", "bool"}},
+        {"let g: fn(i64, f64): str = x", "fn", []string{"i64", "f64"}},
     }
 
     for _, tt := range tests {
@@ -707,8 +762,8 @@ func TestTypeLiteral(t *testing.T) {
         }
 
         for i, subtype := range tt.expectedSubtypesLiteral {
-            if stmt.Identifier.Type.Subtypes[i].Literal != subtype {
-                t.Fatalf("stmt.Subtypes[%d].Literal not '%s'. got=%s", i, subtype, stmt.Identifier.Type.Subtypes[i].Literal)
+            if stmt.Identifier.Type.Subtypes[i].Type.Literal != subtype {
+                t.Fatalf("stmt.Subtypes[%d].Literal not '%s'. got=%s", i, subtype, stmt.Identifier.Type.Subtypes[i].Type.Literal)
             }
         }
     }
@@ -902,6 +957,81 @@ func TestMutStatementForListIndex(t *testing.T) {
     }
 }
 
+func TestMutStatementForFields(t *testing.T) {
+    tests := []struct {
+        input string
+        expectedTarget string
+        expectedExpression string
+    }{
+        {"mut p.x to 3", "p.x", "3"},
+        {"mut line.end.x to + 1", "line.end.x", "(line.end.x + 1)"},
+        {"mut p.tags(0) to \"a\"", "p.tags(0)", "a"},
+        {"mut xs(0) to * 2", "xs(0)", "(xs(0) * 2)"},
+    }
+
+    for _, tt := range tests {
+        tokenizer := tokenizer.New(tt.input)
+        parser := New(tokenizer)
+        program := parser.ParseProgram()
+        checkParserErrors(t, parser)
+
+        stmt, ok := program.Statements[0].(*ast.MutStatement)
+        if !ok {
+            t.Fatalf("program.Statements[0] is not ast.MutStatement. got=%T", program.Statements[0])
+        }
+        if stmt.Identifier.String() != tt.expectedTarget {
+            t.Errorf("stmt.Identifier.String() is not %q. got=%q", tt.expectedTarget, stmt.Identifier.String())
+        }
+        if stmt.Expression.String() != tt.expectedExpression {
+            t.Errorf("stmt.Expression.String() is not %q. got=%q", tt.expectedExpression, stmt.Expression.String())
+        }
+    }
+}
+
+func TestUseStatement(t *testing.T) {
+    tests := []struct {
+        input string
+        expectedPath string
+        expectedName string
+    }{
+        {`use "geom"`, "geom", "geom"},
+        {`use "lib/geom.kimchi"`, "lib/geom.kimchi", "geom"},
+        {`use "../shapes/circle"`, "../shapes/circle", "circle"},
+    }
+
+    for _, tt := range tests {
+        tokenizer := tokenizer.New(tt.input)
+        parser := New(tokenizer)
+        program := parser.ParseProgram()
+
+        checkParserErrors(t, parser)
+
+        if len(program.Statements) != 1 {
+            t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
+        }
+
+        stmt, ok := program.Statements[0].(*ast.UseStatement)
+        if !ok {
+            t.Fatalf("program.Statements[0] is not ast.UseStatement. got=%T", program.Statements[0])
+        }
+
+        if stmt.Path != tt.expectedPath {
+            t.Errorf("stmt.Path is not %q. got=%q", tt.expectedPath, stmt.Path)
+        }
+        if stmt.Name != tt.expectedName {
+            t.Errorf("stmt.Name is not %q. got=%q", tt.expectedName, stmt.Name)
+        }
+    }
+
+    tokenizer := tokenizer.New(`use geom`)
+    parser := New(tokenizer)
+    parser.ParseProgram()
+
+    if len(parser.Errors) == 0 || parser.Errors[0].Message != "expected module path, found 'geom'" {
+        t.Errorf("expected a module path error. got=%v", parser.Errors)
+    }
+}
+
 func TestExeStatement(t *testing.T) {
     input := `exe print("hello")`
 
@@ -1274,6 +1404,778 @@ func TestStructLiteralExpression(t *testing.T) {
     testIdentifierType(t, structLiteral.Fields[1], "bool")
 }
 
+func TestUserDefinedTypeAnnotations(t *testing.T) {
+    input := `
+    let p: Point = Point(1, 2)
+    let f be fn(a: Point, b: list(Point)): Point { return a }
+    `
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+    checkParserErrors(t, parser)
+
+    stmt := program.Statements[0].(*ast.LetStatement)
+    testIdentifierType(t, stmt.Identifier, "Point")
+
+    function := program.Statements[1].(*ast.LetStatement).Expression.(*ast.FunctionLiteral)
+    if function.Parameters[1].Type.String() != "list(Point)" {
+        t.Errorf("parameter type wrong. got=%q", function.Parameters[1].Type.String())
+    }
+    if function.ReturnType.String() != "Point" {
+        t.Errorf("return type wrong. got=%q", function.ReturnType.String())
+    }
+}
+
+func TestInterpolatedStringParsing(t *testing.T) {
+    tests := []struct {
+        input string
+        expectedParts []string
+    }{
+        {`"total: {x + 1}!"`, []string{"total: ", "(x + 1)", "!"}},
+        {`"{a}{b}"`, []string{"a", "b"}},
+        {`"{list(1, 2).len()} items"`, []string{"list(1, 2).len()", " items"}},
+    }
+
+    for _, tt := range tests {
+        tokenizer := tokenizer.New(tt.input)
+        parser := New(tokenizer)
+        program := parser.ParseProgram()
+        checkParserErrors(t, parser)
+
+        stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+        if !ok {
+            t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+        }
+
+        interpolated, ok := stmt.Expression.(*ast.InterpolatedString)
+        if !ok {
+            t.Fatalf("stmt.Expression is not ast.InterpolatedString. got=%T", stmt.Expression)
+        }
+
+        if len(interpolated.Parts) != len(tt.expectedParts) {
+            t.Fatalf("interpolated.Parts has wrong length. want=%d, got=%d", len(tt.expectedParts), len(interpolated.Parts))
+        }
+
+        for i, part := range tt.expectedParts {
+            if interpolated.Parts[i].String() != part {
+                t.Errorf("interpolated.Parts[%d] wrong. want=%q, got=%q", i, part, interpolated.Parts[i].String())
+            }
+        }
+    }
+}
+
+func TestNodePositions(t *testing.T) {
+    input := "let x be 5\nmut x to x + y"
+
+    tokenizer := tokenizer.NewFile("main.kimchi", input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+    checkParserErrors(t, parser)
+
+    if len(program.Statements) != 2 {
+        t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 2, len(program.Statements))
+    }
+
+    stmt, ok := program.Statements[1].(*ast.MutStatement)
+    if !ok {
+        t.Fatalf("program.Statements[1] is not ast.MutStatement. got=%T", program.Statements[1])
+    }
+
+    tests := []struct {
+        node ast.Node
+        expected string
+    }{
+        {program.Statements[0], "main.kimchi:1:1"},
+        {program.Statements[0].(*ast.LetStatement).Identifier, "main.kimchi:1:5"},
+        {program.Statements[0].(*ast.LetStatement).Expression, "main.kimchi:1:10"},
+        {stmt, "main.kimchi:2:1"},
+        {stmt.Expression, "main.kimchi:2:12"},
+        {stmt.Expression.(*ast.InfixExpression).Right, "main.kimchi:2:14"},
+    }
+
+    for i, tt := range tests {
+        if tt.node.Pos().String() != tt.expected {
+            t.Errorf("tests[%d] - position wrong. expected=%s, got=%s", i, tt.expected, tt.node.Pos())
+        }
+    }
+}
+
+func TestErrorPositions(t *testing.T) {
+    input := "let x: i64 = 5\nlet = 5"
+
+    tokenizer := tokenizer.NewFile("main.kimchi", input)
+    parser := New(tokenizer)
+    parser.ParseProgram()
+
+    if len(parser.Errors) == 0 {
+        t.Fatalf("expected parser errors")
+    }
+
+    if !strings.HasPrefix(parser.Errors[0].Error(), "main.kimchi:2:5: ") {
+        t.Fatalf("error is not prefixed with its position. got=%q", parser.Errors[0].Error())
+    }
+}
+func TestIllegalTokenErrors(t *testing.T) {
+    input := "let x be 5\nlet y: str = \"abc"
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    parser.ParseProgram()
+
+    expected := "2:14: unterminated string literal"
+    if len(parser.Errors) != 1 || parser.Errors[0].Error() != expected {
+        t.Fatalf("expected a single error %q. got=%v", expected, parser.Errors)
+    }
+}
+
+func TestNumberLiterals(t *testing.T) {
+    tests := []struct {
+        input string
+        expected interface{}
+    }{
+        {"0xff", 255},
+        {"0XFF", 255},
+        {"0b1010", 10},
+        {"0o17", 15},
+        {"010", 10},
+        {"1_000_000", 1000000},
+        {"0xdead_beef", 3735928559},
+        {"9223372036854775807", 9223372036854775807},
+        {"1e3", 1000.0},
+        {"2.5e-2", 0.025},
+        {"1_000.5", 1000.5},
+    }
+
+    for _, tt := range tests {
+        tokenizer := tokenizer.New(tt.input)
+        parser := New(tokenizer)
+        program := parser.ParseProgram()
+        checkParserErrors(t, parser)
+
+        statement := program.Statements[0].(*ast.ExpressionStatement)
+        testExpressionValue(t, statement.Expression, tt.expected)
+    }
+}
+
+func TestNumberLiteralErrors(t *testing.T) {
+    tests := []struct {
+        input string
+        expected string
+    }{
+        {"let x be 9223372036854775808", "1:10: integer literal 9223372036854775808 is out of range for i64"},
+        {"let x be 0b102", "1:10: invalid integer literal 0b102"},
+        {"let x be 0x", "1:10: invalid integer literal 0x"},
+        {"let x be 1__000", "1:10: invalid integer literal 1__000"},
+        {"let x be 1000_", "1:10: invalid integer literal 1000_"},
+        {"let x be 1e400", "1:10: float literal 1e400 is out of range for f64"},
+        {"let x be 1.2.3", "1:10: malformed number literal 1.2.3"},
+    }
+
+    for _, tt := range tests {
+        tokenizer := tokenizer.New(tt.input)
+        parser := New(tokenizer)
+        parser.ParseProgram()
+
+        if len(parser.Errors) != 1 || parser.Errors[0].Error() != tt.expected {
+            t.Errorf("expected a single error %q. got=%v", tt.expected, parser.Errors)
+        }
+    }
+}
+
+func TestParseErrorDetails(t *testing.T) {
+    input := "let x: i64 = f(1, 2 let y be 3"
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    parser.ParseProgram()
+
+    if len(parser.Errors) != 1 {
+        t.Fatalf("expected 1 error. got=%v", parser.Errors)
+    }
+    err := parser.Errors[0]
+    if err.Code != UNEXPECTED_TOKEN {
+        t.Errorf("wrong code. expected=%s, got=%s", UNEXPECTED_TOKEN, err.Code)
+    }
+    if len(err.Expected) != 1 || err.Expected[0] != "')'" {
+        t.Errorf("wrong expected tokens. got=%q", err.Expected)
+    }
+    if err.Found.Literal != "let" {
+        t.Errorf("wrong found token. got=%q", err.Found.Literal)
+    }
+    if err.Position.Column != 21 {
+        t.Errorf("wrong column. expected=21, got=%d", err.Position.Column)
+    }
+    if err.Error() != "1:21: expected ')', found 'let'" {
+        t.Errorf("wrong message. got=%q", err.Error())
+    }
+}
+
+func TestErrorRecovery(t *testing.T) {
+    input := `
+let a: i64 = (1 +
+let b be fn(x i64): i64 { return x }
+let c: i64 = 5
+let d be fn(): i64 {
+    let e be )
+    return 1 +
+}
+let f be 0b102
+`
+
+    expected := []string{
+        "3:1: expected expression, found 'let'",
+        "3:15: expected ':', found 'i64'",
+        "6:14: expected expression, found ')'",
+        "8:1: expected expression, found '}'",
+        "9:10: invalid integer literal 0b102",
+    }
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+
+    if len(parser.Errors) != len(expected) {
+        t.Fatalf("expected %d errors. got=%v", len(expected), parser.Errors)
+    }
+    for i, err := range parser.Errors {
+        if err.Error() != expected[i] {
+            t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, expected[i], err.Error())
+        }
+    }
+
+    if len(program.Statements) != 5 {
+        t.Errorf("expected 5 statements to be recovered. got=%d", len(program.Statements))
+    }
+}
+
+func TestUseErrorRecovery(t *testing.T) {
+    input := "let a: i64 = (1 +\nuse \"math\"\nlet b be 2"
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+
+    if len(parser.Errors) != 1 || parser.Errors[0].Error() != "2:1: expected expression, found 'use'" {
+        t.Fatalf("expected a single error at use. got=%v", parser.Errors)
+    }
+    if len(program.Statements) != 3 {
+        t.Fatalf("expected 3 statements to be recovered. got=%d", len(program.Statements))
+    }
+    if _, ok := program.Statements[1].(*ast.UseStatement); !ok {
+        t.Errorf("program.Statements[1] is not ast.UseStatement. got=%T", program.Statements[1])
+    }
+}
+
+func TestRenderParseError(t *testing.T) {
+    input := "let x be 1\n\tlet y: i64 = f(1, 2 let z be 3"
+
+    tokenizer := tokenizer.NewFile("main.kimchi", input)
+    parser := New(tokenizer)
+    parser.ParseProgram()
+
+    expected := "error[P001]: expected ')', found 'let'\n" +
+        " --> main.kimchi:2:22\n" +
+        "  |\n" +
+        "2 | \tlet y: i64 = f(1, 2 let z be 3\n" +
+        "  | \t                    ^^^\n"
+
+    if len(parser.Errors) != 1 {
+        t.Fatalf("expected 1 error. got=%v", parser.Errors)
+    }
+    if rendered := parser.Errors[0].Render(input); rendered != expected {
+        t.Errorf("wrong rendering. expected=\n%s\ngot=\n%s", expected, rendered)
+    }
+}
+
+func TestCompoundTypeLiterals(t *testing.T) {
+    tests := []struct {
+        input string
+        expected string
+    }{
+        {"let x: list(list(i64)) = x", "list(list(i64))"},
+        {"let x: map(str, list(f64)) = x", "map(str, list(f64))"},
+        {"let x: vec(f64) = x", "vec(f64)"},
+        {"let x: tuple(i64, map(str, set(i64))) = x", "tuple(i64, map(str, set(i64)))"},
+        {"let x: fn(i64, i64): i64 = x", "fn(i64, i64): i64"},
+        {"let x: fn(): none = x", "fn(): none"},
+        {"let x: fn(fn(i64): bool, list(i64)): list(i64) = x", "fn(fn(i64): bool, list(i64)): list(i64)"},
+        {"let x: fn(i64): fn(i64): i64 = x", "fn(i64): fn(i64): i64"},
+        {"let x: list(fn) = x", "list(fn)"},
+        {"let x: list = x", "list"},
+        {"let x: map = x", "map"},
+        {"let x: fn = x", "fn"},
+    }
+
+    for _, tt := range tests {
+        tokenizer := tokenizer.New(tt.input)
+        parser := New(tokenizer)
+        program := parser.ParseProgram()
+        checkParserErrors(t, parser)
+
+        stmt := program.Statements[0].(*ast.LetStatement)
+        if stmt.Identifier.Type.String() != tt.expected {
+            t.Errorf("type wrong. expected=%q, got=%q", tt.expected, stmt.Identifier.Type.String())
+        }
+        if stmt.Expression.String() != "x" {
+            t.Errorf("expression wrong. expected=%q, got=%q", "x", stmt.Expression.String())
+        }
+    }
+}
+
+func TestFunctionTypeParameters(t *testing.T) {
+    input := `let apply be fn(f: fn(i64): i64, xs: list(list(i64))): map(str, list(i64)) { return f }`
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+    checkParserErrors(t, parser)
+
+    function := program.Statements[0].(*ast.LetStatement).Expression.(*ast.FunctionLiteral)
+    if len(function.Parameters) != 2 {
+        t.Fatalf("function literal parameters wrong. want 2, got=%d", len(function.Parameters))
+    }
+    if function.Parameters[0].Type.String() != "fn(i64): i64" {
+        t.Errorf("parameter type wrong. got=%q", function.Parameters[0].Type.String())
+    }
+    if function.Parameters[1].Type.String() != "list(list(i64))" {
+        t.Errorf("parameter type wrong. got=%q", function.Parameters[1].Type.String())
+    }
+    if function.ReturnType.String() != "map(str, list(i64))" {
+        t.Errorf("return type wrong. got=%q", function.ReturnType.String())
+    }
+}
+
+func TestTypeLiteralErrors(t *testing.T) {
+    tests := []struct {
+        input string
+        expected string
+    }{
+        {"let x: list(i64, str) = x", "1:8: list type takes 1 element type, got 2"},
+        {"let x: map(str) = x", "1:8: map type takes 2 element types, got 1"},
+        {"let x: tuple() = x", "1:8: tuple type needs at least one element type"},
+        {"let x: fn(i64) = x", "1:16: expected ':', found '='"},
+        {"let x: list(5) = x", "1:13: expected type, found '5'"},
+    }
+
+    for _, tt := range tests {
+        tokenizer := tokenizer.New(tt.input)
+        parser := New(tokenizer)
+        parser.ParseProgram()
+
+        if len(parser.Errors) != 1 || parser.Errors[0].Error() != tt.expected {
+            t.Errorf("expected a single error %q. got=%v", tt.expected, parser.Errors)
+        }
+    }
+}
+
+func TestEnumLiteralParsing(t *testing.T) {
+    input := `enum(Circle(radius: f64), Rect(w: f64, h: f64), Empty)`
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+    checkParserErrors(t, parser)
+
+    stmt := program.Statements[0].(*ast.ExpressionStatement)
+    enum, ok := stmt.Expression.(*ast.EnumLiteral)
+    if !ok {
+        t.Fatalf("stmt.Expression is not ast.EnumLiteral. got=%T", stmt.Expression)
+    }
+
+    if len(enum.Variants) != 3 {
+        t.Fatalf("enum.Variants does not contain 3 variants. got=%d", len(enum.Variants))
+    }
+    if enum.Variants[0].Name != "Circle" || len(enum.Variants[0].Fields) != 1 {
+        t.Errorf("wrong first variant. got=%s", enum.Variants[0].String())
+    }
+    testIdentifierType(t, enum.Variants[1].Fields[1], "f64")
+    if enum.Variants[2].Name != "Empty" || len(enum.Variants[2].Fields) != 0 {
+        t.Errorf("wrong last variant. got=%s", enum.Variants[2].String())
+    }
+    if enum.String() != "enum(Circle(radius: f64), Rect(w: f64, h: f64), Empty)" {
+        t.Errorf("wrong string. got=%q", enum.String())
+    }
+}
+
+func TestMatchExpressionParsing(t *testing.T) {
+    input := `
+    match shape {
+        Shape.Circle(r) if r > 1.0: r * r
+        Shape.Rect(w, h): { w * h }
+        list(first, ...rest): first,
+        -1: 0
+        _: 0
+    }
+    `
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+    checkParserErrors(t, parser)
+
+    if len(program.Statements) != 1 {
+        t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+    }
+
+    stmt := program.Statements[0].(*ast.ExpressionStatement)
+    match, ok := stmt.Expression.(*ast.MatchExpression)
+    if !ok {
+        t.Fatalf("stmt.Expression is not ast.MatchExpression. got=%T", stmt.Expression)
+    }
+
+    testIdentifier(t, match.Subject, "shape")
+
+    expected := []struct {
+        pattern string
+        guard string
+        body string
+    }{
+        {"Shape.Circle(r)", "(r > 1)", "(r * r)"},
+        {"Shape.Rect(w, h)", "", "(w * h)"},
+        {"list(first, ...rest)", "", "first"},
+        {"(-1)", "", "0"},
+        {"_", "", "0"},
+    }
+
+    if len(match.Arms) != len(expected) {
+        t.Fatalf("match.Arms does not contain %d arms. got=%d", len(expected), len(match.Arms))
+    }
+    for i, arm := range match.Arms {
+        if arm.Pattern.String() != expected[i].pattern {
+            t.Errorf("arms[%d] pattern wrong. expected=%q, got=%q", i, expected[i].pattern, arm.Pattern.String())
+        }
+        if (arm.Guard == nil && expected[i].guard != "") || (arm.Guard != nil && arm.Guard.String() != expected[i].guard) {
+            t.Errorf("arms[%d] guard wrong. expected=%q, got=%v", i, expected[i].guard, arm.Guard)
+        }
+        if arm.Body.String() != expected[i].body {
+            t.Errorf("arms[%d] body wrong. expected=%q, got=%q", i, expected[i].body, arm.Body.String())
+        }
+    }
+}
+
+
+func TestTupleParsing(t *testing.T) {
+    tests := []struct {
+        input string
+        expected string
+    }{
+        {`tuple(1, "a", x)`, `tuple(1, a, x)`},
+        {`let q, r be divmod(7, 2)`, `let q, r = divmod(7, 2);`},
+        {`let _, r be divmod(7, 2)`, `let _, r = divmod(7, 2);`},
+        {`let a, b be 1, 2`, `let a, b = tuple(1, 2);`},
+        {`mut a, b to b, a`, `mut tuple(a, b) to tuple(b, a)`},
+        {`return a, b + 1`, `return tuple(a, (b + 1));`},
+    }
+
+    for _, tt := range tests {
+        tokenizer := tokenizer.New(tt.input)
+        parser := New(tokenizer)
+        program := parser.ParseProgram()
+        checkParserErrors(t, parser)
+
+        if len(program.Statements) != 1 {
+            t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+        }
+        if program.Statements[0].String() != tt.expected {
+            t.Errorf("wrong string. expected=%q, got=%q", tt.expected, program.Statements[0].String())
+        }
+    }
+
+    tokenizer := tokenizer.New(`let q, r be divmod(7, 2)`)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+    stmt := program.Statements[0].(*ast.LetStatement)
+    if stmt.Identifier != nil || len(stmt.Targets) != 2 {
+        t.Fatalf("stmt does not have 2 targets. got=%v", stmt.Targets)
+    }
+    testIdentifier(t, stmt.Targets[1], "r")
+}
+
+
+func TestSetLiteralParsing(t *testing.T) {
+    input := `set(1, 2 * 2, x)`
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+    checkParserErrors(t, parser)
+
+    stmt := program.Statements[0].(*ast.ExpressionStatement)
+    set, ok := stmt.Expression.(*ast.SetLiteral)
+    if !ok {
+        t.Fatalf("stmt.Expression is not ast.SetLiteral. got=%T", stmt.Expression)
+    }
+
+    if len(set.Elements) != 3 {
+        t.Fatalf("len(set.Elements) not 3. got=%d", len(set.Elements))
+    }
+    testIntegerLiteral(t, set.Elements[0], 1)
+    testInfixExpression(t, set.Elements[1], 2, "*", 2)
+    testIdentifier(t, set.Elements[2], "x")
+}
+
+
+func TestVecLiteralParsing(t *testing.T) {
+    input := `vec(1.5, -2.0, x) * 2.0`
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+    checkParserErrors(t, parser)
+
+    stmt := program.Statements[0].(*ast.ExpressionStatement)
+    infix, ok := stmt.Expression.(*ast.InfixExpression)
+    if !ok {
+        t.Fatalf("stmt.Expression is not ast.InfixExpression. got=%T", stmt.Expression)
+    }
+    vec, ok := infix.Left.(*ast.VecLiteral)
+    if !ok {
+        t.Fatalf("infix.Left is not ast.VecLiteral. got=%T", infix.Left)
+    }
+
+    if len(vec.Elements) != 3 {
+        t.Fatalf("len(vec.Elements) not 3. got=%d", len(vec.Elements))
+    }
+    if vec.String() != "vec(1.5, (-2), x)" {
+        t.Errorf("wrong string. got=%q", vec.String())
+    }
+}
+
+
+func TestStructMethodsParsing(t *testing.T) {
+    input := `
+    let Counter be struct(count: i64) {
+        let get be fn(): i64 { return self.count }
+        let add be fn(n: i64): none { mut self.count to + n }
+    }
+    exe c.add(1)
+    `
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+    checkParserErrors(t, parser)
+
+    if len(program.Statements) != 2 {
+        t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+    }
+
+    stmt := program.Statements[0].(*ast.LetStatement)
+    literal, ok := stmt.Expression.(*ast.StructLiteral)
+    if !ok {
+        t.Fatalf("stmt.Expression is not ast.StructLiteral. got=%T", stmt.Expression)
+    }
+    if len(literal.Methods) != 2 {
+        t.Fatalf("literal.Methods does not contain 2 methods. got=%d", len(literal.Methods))
+    }
+    if literal.Methods[1].Identifier.Name != "add" {
+        t.Errorf("wrong method name. got=%s", literal.Methods[1].Identifier.Name)
+    }
+
+    add := literal.Methods[1].Expression.(*ast.FunctionLiteral)
+    mut, ok := add.Body.Statements[0].(*ast.MutStatement)
+    if !ok {
+        t.Fatalf("method body is not ast.MutStatement. got=%T", add.Body.Statements[0])
+    }
+    if mut.String() != "mut self.count to (self.count + n)" {
+        t.Errorf("wrong mut statement. got=%q", mut.String())
+    }
+
+    exe := program.Statements[1].(*ast.ExeStatement)
+    if exe.String() != "c.add(1)" {
+        t.Errorf("wrong exe statement. got=%q", exe.String())
+    }
+}
+
+func TestStructMethodErrors(t *testing.T) {
+    input := `let Point be struct(x: i64) { let y be 1 }`
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    parser.ParseProgram()
+
+    if len(parser.Errors) == 0 {
+        t.Fatalf("expected parser errors")
+    }
+    if parser.Errors[0].Code != INVALID_METHOD {
+        t.Errorf("wrong error code. expected=%s, got=%s", INVALID_METHOD, parser.Errors[0].Code)
+    }
+}
+
+
+func TestInterfaceParsing(t *testing.T) {
+    input := `
+    let Shape be interface(area: fn(): f64, scale: fn(f64): none)
+    let Square be struct(s: f64) is Shape, Named { let area be fn(): f64 { return self.s * self.s } }
+    `
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+    checkParserErrors(t, parser)
+
+    if len(program.Statements) != 2 {
+        t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+    }
+
+    iface, ok := program.Statements[0].(*ast.LetStatement).Expression.(*ast.InterfaceLiteral)
+    if !ok {
+        t.Fatalf("expression is not ast.InterfaceLiteral. got=%T", program.Statements[0].(*ast.LetStatement).Expression)
+    }
+    if iface.String() != "interface(area: fn(): f64, scale: fn(f64): none)" {
+        t.Errorf("wrong string. got=%q", iface.String())
+    }
+
+    literal := program.Statements[1].(*ast.LetStatement).Expression.(*ast.StructLiteral)
+    if len(literal.Interfaces) != 2 {
+        t.Fatalf("literal.Interfaces does not contain 2 names. got=%d", len(literal.Interfaces))
+    }
+    testIdentifier(t, literal.Interfaces[0], "Shape")
+    testIdentifier(t, literal.Interfaces[1], "Named")
+    if len(literal.Methods) != 1 {
+        t.Errorf("literal.Methods does not contain 1 method. got=%d", len(literal.Methods))
+    }
+}
+
+func TestInterfaceErrors(t *testing.T) {
+    input := `let Shape be interface(area: f64)`
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    parser.ParseProgram()
+
+    if len(parser.Errors) == 0 {
+        t.Fatalf("expected parser errors")
+    }
+    expected := "interface methods must have function types, got f64"
+    if parser.Errors[0].Code != INVALID_TYPE || parser.Errors[0].Message != expected {
+        t.Errorf("wrong error. got=%s %q", parser.Errors[0].Code, parser.Errors[0].Message)
+    }
+}
+
+
+func TestFunctionParameterOptions(t *testing.T) {
+    input := `fn(name: str, greeting: str = "hello", rest: ...i64): none { }`
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+    checkParserErrors(t, parser)
+
+    function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+    if len(function.Parameters) != 3 {
+        t.Fatalf("function.Parameters does not contain 3 parameters. got=%d", len(function.Parameters))
+    }
+    if function.Parameters[0].Default != nil || function.Parameters[0].Variadic {
+        t.Errorf("parameter name should be plain")
+    }
+    testStringLiteral(t, function.Parameters[1].Default, "hello")
+    if !function.Parameters[2].Variadic {
+        t.Errorf("parameter rest is not variadic")
+    }
+    testIdentifierType(t, function.Parameters[2], "i64")
+}
+
+func TestGenericFunctionParsing(t *testing.T) {
+    input := `fn[K, V](m: map(K, V), key: K): V { return m(key) }`
+
+    tokenizer := tokenizer.New(input)
+    parser := New(tokenizer)
+    program := parser.ParseProgram()
+    checkParserErrors(t, parser)
+
+    function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+    if len(function.TypeParameters) != 2 {
+        t.Fatalf("function.TypeParameters does not contain 2 names. got=%d", len(function.TypeParameters))
+    }
+    if function.TypeParameters[0].Name != "K" || function.TypeParameters[1].Name != "V" {
+        t.Errorf("type parameters wrong. got=%s, %s", function.TypeParameters[0].Name, function.TypeParameters[1].Name)
+    }
+    if function.Parameters[0].Type.String() != "map(K, V)" {
+        t.Errorf("parameter type wrong. got=%q", function.Parameters[0].Type.String())
+    }
+    if function.String() != "fn[K, V](m, key): Vreturn m(key);" {
+        t.Errorf("function.String() wrong. got=%q", function.String())
+    }
+}
+
+func TestGenericFunctionErrors(t *testing.T) {
+    tests := []struct {
+        input string
+        expected string
+    }{
+        {"fn[](x: i64): i64 { return x }", "1:4: expected identifier, found ']'"},
+        {"fn[T, T](x: T): T { return x }", "1:7: duplicate type parameter T"},
+        {"fn[T(x: T): T { return x }", "1:5: expected ']', found '('"},
+    }
+
+    for _, tt := range tests {
+        tokenizer := tokenizer.New(tt.input)
+        parser := New(tokenizer)
+        parser.ParseProgram()
+
+        if len(parser.Errors) != 1 || parser.Errors[0].Error() != tt.expected {
+            t.Errorf("expected a single error %q. got=%v", tt.expected, parser.Errors)
+        }
+    }
+}
+
+func TestNamedArgumentParsing(t *testing.T) {
+    tests := []struct {
+        input string
+        expected string
+    }{
+        {`f(1, n: 2 + 3)`, "f(1, n: (2 + 3))"},
+        {`xs.f(n: a)`, "xs.f(n: a)"},
+        {`f(n: g(m: 1))`, "f(n: g(m: 1))"},
+    }
+
+    for _, tt := range tests {
+        tokenizer := tokenizer.New(tt.input)
+        parser := New(tokenizer)
+        program := parser.ParseProgram()
+        checkParserErrors(t, parser)
+
+        if program.Statements[0].String() != tt.expected {
+            t.Errorf("wrong string. expected=%q, got=%q", tt.expected, program.Statements[0].String())
+        }
+    }
+
+    tokenizer := tokenizer.New(`fn(xs: ...i64, n: i64): none { }`)
+    parser := New(tokenizer)
+    parser.ParseProgram()
+    if len(parser.Errors) == 0 || parser.Errors[0].Message != "variadic parameter xs must be the last one" {
+        t.Errorf("expected variadic parameter error. got=%v", parser.Errors)
+    }
+}
+
+
+func TestInExpressionParsing(t *testing.T) {
+    tests := []struct {
+        input string
+        expected string
+    }{
+        {`x in xs`, "(x in xs)"},
+        {`x + 1 in xs`, "((x + 1) in xs)"},
+        {`x in xs and y in ys`, "((x in xs) and (y in ys))"},
+        {`not (x in xs)`, "(not(x in xs))"},
+        {`x in xs is false`, "((x in xs) is false)"},
+        {`x in (0 to 10)`, "(x in (0 to 10))"},
+    }
+
+    for _, tt := range tests {
+        tokenizer := tokenizer.New(tt.input)
+        parser := New(tokenizer)
+        program := parser.ParseProgram()
+        checkParserErrors(t, parser)
+
+        if program.String() != tt.expected {
+            t.Errorf("wrong string. expected=%q, got=%q", tt.expected, program.String())
+        }
+    }
+}
+
+
 // =======
 // HELPERS
 // =======
@@ -1285,7 +2187,7 @@ func checkParserErrors(t *testing.T, parser *Parser) {
 
     t.Errorf("parser has %d errors", len(errors))
     for _, msg := range errors {
-        t.Errorf("parser error: %q", msg)
+        t.Errorf("parser error: %q", msg.Error())
     }
     t.FailNow()
 }
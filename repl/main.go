@@ -8,6 +8,7 @@ import (
     "bufio"
     "kimchi/tokenizer"
     "kimchi/parser"
+    "kimchi/checker"
     "kimchi/evaluator"
     "kimchi/object"
 )
@@ -27,6 +28,8 @@ func main() {
 func start(in io.Reader, out io.Writer) {
     scanner := bufio.NewScanner(in)
     env := object.NewEnvironment()
+    checker := checker.New()
+    checker.Modules = evaluator.CheckModule
 
     for {
         fmt.Printf(PROMPT)
@@ -41,7 +44,11 @@ func start(in io.Reader, out io.Writer) {
 
         program := parser.ParseProgram()
         if len(parser.Errors) != 0 {
-            printParserErrors(out, parser.Errors)
+            printParserErrors(out, parser.Errors, line)
+            continue
+        }
+        if errors := checker.Check(program); len(errors) != 0 {
+            printCheckErrors(out, errors, line)
             continue
         }
 
@@ -53,9 +60,15 @@ func start(in io.Reader, out io.Writer) {
     }
 }
 
-func printParserErrors(out io.Writer, errors []string) {
-    io.WriteString(out, "Parser panicked! Errors: \n")
-    for _, msg := range errors {
-        io.WriteString(out, "\t"+msg+"\n")
+func printParserErrors(out io.Writer, errors []*parser.ParseError, source string) {
+    for _, err := range errors {
+        io.WriteString(out, err.Render(source))
+        io.WriteString(out, "\n")
+    }
+}
+func printCheckErrors(out io.Writer, errors []*checker.CheckError, source string) {
+    for _, err := range errors {
+        io.WriteString(out, err.Render(source))
+        io.WriteString(out, "\n")
     }
 }
@@ -0,0 +1,27 @@
+package std
+
+import (
+    "embed"
+)
+
+// The standard library is written in Kimchi and shipped inside the binary.
+// The prelude is available in every program, the other files are modules
+// that can be loaded with use.
+//go:embed *.kimchi
+var files embed.FS
+
+const PRELUDE = "prelude.kimchi"
+
+func Prelude() string {
+    content, _ := files.ReadFile(PRELUDE)
+    return string(content)
+}
+
+// Returns the source of the module with the given file name, like math.kimchi
+func Module(name string) (string, bool) {
+    if name == PRELUDE { return "", false }
+
+    content, err := files.ReadFile(name)
+    if err != nil { return "", false }
+    return string(content), true
+}
@@ -1,11 +1,31 @@
 package token
 
-import "strings"
+import (
+    "fmt"
+    "strings"
+)
 
 type Token struct {
     Type int
     Subtype int
     Literal string
+    Position Position
+}
+
+type Position struct {
+    File string
+    Line int
+    Column int
+    Offset int
+}
+func (self Position) IsValid() bool {
+    return self.Line > 0
+}
+func (self Position) String() string {
+    if self.File == "" {
+        return fmt.Sprintf("%d:%d", self.Line, self.Column)
+    }
+    return fmt.Sprintf("%s:%d:%d", self.File, self.Line, self.Column)
 }
 
 const (
@@ -29,6 +49,7 @@ const (
     TO
     EXE
     RETURN
+    USE
 
     // Primitive types
     I64
@@ -43,6 +64,7 @@ const (
     FN
     STRUCT
     ENUM
+    INTERFACE
     MAP
     LIST
     TUPLE
@@ -90,158 +112,213 @@ const (
     RPAREN
     LBRACE
     RBRACE
+    LBRACKET
+    RBRACKET
     UNDERSCORE
+    ELLIPSIS
+
+    // String templates
+    TEMPLATE_HEAD
+    TEMPLATE_MIDDLE
+    TEMPLATE_TAIL
 )
 
 var keywords = map[string]Token {
     // Statements
-    "let": {KEYWORD, LET, "let"},
-    "return": {KEYWORD, RETURN, "return"},
-    "be": {KEYWORD, BE, "be"},
-    "mut": {KEYWORD, MUT, "mut"},
-    "to": {KEYWORD, TO, "to"},
-    "exe": {KEYWORD, EXE, "exe"},
+    "let": {Type: KEYWORD, Subtype: LET, Literal: "let"},
+    "return": {Type: KEYWORD, Subtype: RETURN, Literal: "return"},
+    "be": {Type: KEYWORD, Subtype: BE, Literal: "be"},
+    "mut": {Type: KEYWORD, Subtype: MUT, Literal: "mut"},
+    "to": {Type: KEYWORD, Subtype: TO, Literal: "to"},
+    "exe": {Type: KEYWORD, Subtype: EXE, Literal: "exe"},
+    "use": {Type: KEYWORD, Subtype: USE, Literal: "use"},
     
     // Primitive types
-    "i64": {TYPE, I64, "i64"},
-    "f64": {TYPE, F64, "f64"},
-    "str": {TYPE, STR, "str"},
-    "bool": {TYPE, BOOL, "bool"},
-    "none": {TYPE, NONE, "none"},
-    "true": {LITERAL, TRUE, "true"},
-    "false": {LITERAL, FALSE, "false"},
+    "i64": {Type: TYPE, Subtype: I64, Literal: "i64"},
+    "f64": {Type: TYPE, Subtype: F64, Literal: "f64"},
+    "str": {Type: TYPE, Subtype: STR, Literal: "str"},
+    "bool": {Type: TYPE, Subtype: BOOL, Literal: "bool"},
+    "none": {Type: TYPE, Subtype: NONE, Literal: "none"},
+    "true": {Type: LITERAL, Subtype: TRUE, Literal: "true"},
+    "false": {Type: LITERAL, Subtype: FALSE, Literal: "false"},
 
     // Complex types
-    "fn": {TYPE, FN, "fn"},
-    "struct": {TYPE, STRUCT, "struct"},
-    "enum": {TYPE, ENUM, "enum"},
-    "map": {TYPE, MAP, "map"},
-    "list": {TYPE, LIST, "list"},
-    "tuple": {TYPE, TUPLE, "tuple"},
-    "vec": {TYPE, VEC, "vec"},
-    "set": {TYPE, SET, "set"},
+    "fn": {Type: TYPE, Subtype: FN, Literal: "fn"},
+    "struct": {Type: TYPE, Subtype: STRUCT, Literal: "struct"},
+    "enum": {Type: TYPE, Subtype: ENUM, Literal: "enum"},
+    "interface": {Type: TYPE, Subtype: INTERFACE, Literal: "interface"},
+    "map": {Type: TYPE, Subtype: MAP, Literal: "map"},
+    "list": {Type: TYPE, Subtype: LIST, Literal: "list"},
+    "tuple": {Type: TYPE, Subtype: TUPLE, Literal: "tuple"},
+    "vec": {Type: TYPE, Subtype: VEC, Literal: "vec"},
+    "set": {Type: TYPE, Subtype: SET, Literal: "set"},
 
     // Conditionals
-    "if": {KEYWORD, IF, "if"},
-    "else": {KEYWORD, ELSE, "else"},
-    "match": {KEYWORD, MATCH, "match"},
+    "if": {Type: KEYWORD, Subtype: IF, Literal: "if"},
+    "else": {Type: KEYWORD, Subtype: ELSE, Literal: "else"},
+    "match": {Type: KEYWORD, Subtype: MATCH, Literal: "match"},
 
     // Loops
-    "for": {KEYWORD, FOR, "for"},
-    "while": {KEYWORD, WHILE, "while"},
-    "continue": {KEYWORD, CONTINUE, "continue"},
-    "break": {KEYWORD, BREAK, "break"},
+    "for": {Type: KEYWORD, Subtype: FOR, Literal: "for"},
+    "while": {Type: KEYWORD, Subtype: WHILE, Literal: "while"},
+    "continue": {Type: KEYWORD, Subtype: CONTINUE, Literal: "continue"},
+    "break": {Type: KEYWORD, Subtype: BREAK, Literal: "break"},
 
     // Other
-    "pass": {KEYWORD, PASS, "pass"},
-    "in": {KEYWORD, IN, "in"},
-    "self": {KEYWORD, SELF, "self"},
+    "pass": {Type: KEYWORD, Subtype: PASS, Literal: "pass"},
+    "in": {Type: KEYWORD, Subtype: IN, Literal: "in"},
+    "self": {Type: KEYWORD, Subtype: SELF, Literal: "self"},
 
     // Operators
-    "and": {OPERATOR, AND, "and"},
-    "or": {OPERATOR, OR, "or"},
-    "not": {OPERATOR, NOT, "not"},
-    "is": {OPERATOR, IS, "is"},
-    "is_not": {OPERATOR, IS_NOT, "is_not"},
+    "and": {Type: OPERATOR, Subtype: AND, Literal: "and"},
+    "or": {Type: OPERATOR, Subtype: OR, Literal: "or"},
+    "not": {Type: OPERATOR, Subtype: NOT, Literal: "not"},
+    "is": {Type: OPERATOR, Subtype: IS, Literal: "is"},
+    "is_not": {Type: OPERATOR, Subtype: IS_NOT, Literal: "is_not"},
 }
 
-var chars = map[byte]Token {
-    0: {EOF, EOF, "EOF"},
+var chars = map[rune]Token {
+    0: {Type: EOF, Subtype: EOF, Literal: "EOF"},
 
     // Operators
-    '=': {OPERATOR, ASSIGN, "="},
-    '+': {OPERATOR, PLUS, "+"},
-    '-': {OPERATOR, MINUS, "-"},
-    '*': {OPERATOR, ASTERISK, "*"},
-    '/': {OPERATOR, SLASH, "/"},
-    '%': {OPERATOR, PERCENT, "%"},
-    '<': {OPERATOR, LT, "<"},
-    '>': {OPERATOR, GT, ">"},
+    '=': {Type: OPERATOR, Subtype: ASSIGN, Literal: "="},
+    '+': {Type: OPERATOR, Subtype: PLUS, Literal: "+"},
+    '-': {Type: OPERATOR, Subtype: MINUS, Literal: "-"},
+    '*': {Type: OPERATOR, Subtype: ASTERISK, Literal: "*"},
+    '/': {Type: OPERATOR, Subtype: SLASH, Literal: "/"},
+    '%': {Type: OPERATOR, Subtype: PERCENT, Literal: "%"},
+    '<': {Type: OPERATOR, Subtype: LT, Literal: "<"},
+    '>': {Type: OPERATOR, Subtype: GT, Literal: ">"},
 
     // Delimiters
-    ':': {DELIMITER, COLON, ":"},
-    ',': {DELIMITER, COMMA, ","},
-    '.': {DELIMITER, DOT, "."},
-    '(': {DELIMITER, LPAREN, "("},
-    ')': {DELIMITER, RPAREN, ")"},
-    '{': {DELIMITER, LBRACE, "{"},
-    '}': {DELIMITER, RBRACE, "}"},
-    '_': {DELIMITER, UNDERSCORE, "_"},
+    ':': {Type: DELIMITER, Subtype: COLON, Literal: ":"},
+    ',': {Type: DELIMITER, Subtype: COMMA, Literal: ","},
+    '.': {Type: DELIMITER, Subtype: DOT, Literal: "."},
+    '(': {Type: DELIMITER, Subtype: LPAREN, Literal: "("},
+    ')': {Type: DELIMITER, Subtype: RPAREN, Literal: ")"},
+    '{': {Type: DELIMITER, Subtype: LBRACE, Literal: "{"},
+    '}': {Type: DELIMITER, Subtype: RBRACE, Literal: "}"},
+    '[': {Type: DELIMITER, Subtype: LBRACKET, Literal: "["},
+    ']': {Type: DELIMITER, Subtype: RBRACKET, Literal: "]"},
+    '_': {Type: DELIMITER, Subtype: UNDERSCORE, Literal: "_"},
 }
 
 var twoChars = map[string]Token {
-    "<=": {OPERATOR, LTE, "<="},
-    ">=": {OPERATOR, GTE, ">="},
+    "<=": {Type: OPERATOR, Subtype: LTE, Literal: "<="},
+    ">=": {Type: OPERATOR, Subtype: GTE, Literal: ">="},
+}
+
+var threeChars = map[string]Token {
+    "...": {Type: DELIMITER, Subtype: ELLIPSIS, Literal: "..."},
+}
+
+// Human readable names, used in error messages. The names of keywords,
+// operators and delimiters are filled in from the tables above.
+var names = map[int]string {
+    ILLEGAL: "illegal token",
+    EOF: "end of file",
+    KEYWORD: "keyword",
+    IDENTIFIER: "identifier",
+    TYPE: "type",
+    LITERAL: "literal",
+    OPERATOR: "operator",
+    DELIMITER: "delimiter",
+    TEMPLATE_HEAD: "string",
+    TEMPLATE_MIDDLE: "string",
+    TEMPLATE_TAIL: "end of string",
+}
+
+func init() {
+    for _, table := range []map[string]Token{keywords, twoChars, threeChars} {
+        for _, token := range table {
+            if _, ok := names[token.Subtype]; !ok {
+                names[token.Subtype] = "'" + token.Literal + "'"
+            }
+        }
+    }
+    for _, token := range chars {
+        if _, ok := names[token.Subtype]; !ok {
+            names[token.Subtype] = "'" + token.Literal + "'"
+        }
+    }
 }
 
 // ==============
 // PUBLIC METHODS
 // ==============
+func Name(tokenType int) string {
+    if name, ok := names[tokenType]; ok {
+        return name
+    }
+    return fmt.Sprintf("token %d", tokenType)
+}
+
 func NewIdentifier(identifier string) Token {
     if keyword, ok := keywords[identifier]; ok {
         return keyword
     }
 
-    return Token{IDENTIFIER, IDENTIFIER, identifier}
+    return Token{Type: IDENTIFIER, Subtype: IDENTIFIER, Literal: identifier}
 }
 
 func NewNumber(number string) Token {
-    if strings.Contains(number, ".") {
-        return Token{LITERAL, F64, number}
+    prefixed := len(number) > 1 && number[0] == '0' && strings.ContainsRune("xXbBoO", rune(number[1]))
+    if !prefixed && strings.ContainsAny(number, ".eE") {
+        return Token{Type: LITERAL, Subtype: F64, Literal: number}
     }
 
-    return Token{LITERAL, I64, number}
+    return Token{Type: LITERAL, Subtype: I64, Literal: number}
 }
 
 func NewString(str string) Token {
-    return Token{LITERAL, STR, str}
+    return Token{Type: LITERAL, Subtype: STR, Literal: str}
+}
+
+func NewTemplate(templateType int, str string) Token {
+    return Token{Type: LITERAL, Subtype: templateType, Literal: str}
+}
+
+func NewIllegal(message string) Token {
+    return Token{Type: ILLEGAL, Subtype: ILLEGAL, Literal: message}
 }
 
-func NewChar(char byte) Token {
+func NewChar(char rune) Token {
     if token, ok := chars[char] ; ok {
         return token
     }
 
-    return Token{ILLEGAL, ILLEGAL, string(char)}
+    return Token{Type: ILLEGAL, Subtype: ILLEGAL, Literal: string(char)}
 }
 
-func NewTwoChar(char1 byte, char2 byte) Token {
+func NewTwoChar(char1 rune, char2 rune) Token {
     if token, ok := twoChars[string(char1) + string(char2)] ; ok {
         return token
     }
 
-    return Token{ILLEGAL, ILLEGAL, string(char1) + string(char2)}
+    return Token{Type: ILLEGAL, Subtype: ILLEGAL, Literal: string(char1) + string(char2)}
+}
+
+func NewThreeChar(char1 rune, char2 rune, char3 rune) Token {
+    if token, ok := threeChars[string(char1) + string(char2) + string(char3)] ; ok {
+        return token
+    }
+
+    return Token{Type: ILLEGAL, Subtype: ILLEGAL, Literal: string(char1) + string(char2) + string(char3)}
 }
 
 func NewFromType(tokenType int) Token {
     switch tokenType {
     case I64:
-        return Token{TYPE, I64, "i64"}
+        return Token{Type: TYPE, Subtype: I64, Literal: "i64"}
     case F64:
-        return Token{TYPE, F64, "f64"}
-    case STR:
-        return Token{TYPE, STR, "str"}
+        return Token{Type: TYPE, Subtype: F64, Literal: "f64"}
+    case STR, TEMPLATE_HEAD:
+        return Token{Type: TYPE, Subtype: STR, Literal: "str"}
     case TRUE:
-        return Token{TYPE, BOOL, "bool"}
+        return Token{Type: TYPE, Subtype: BOOL, Literal: "bool"}
     case FALSE:
-        return Token{TYPE, BOOL, "bool"}
+        return Token{Type: TYPE, Subtype: BOOL, Literal: "bool"}
     default:
-        return Token{ILLEGAL, ILLEGAL, "ILLEGAL"}
+        return Token{Type: ILLEGAL, Subtype: ILLEGAL, Literal: "ILLEGAL"}
     }
 }
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
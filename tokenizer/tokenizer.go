@@ -1,21 +1,39 @@
 package tokenizer
 
 import (
+    "strings"
+    "unicode"
+    "unicode/utf8"
     "kimchi/token"
 )
 
 type Tokenizer struct {
+    file string
     input string
     position int
     peekPosition int
-    char byte
+    char rune
+    line int
+    column int
+    templates []template
+}
+
+// An open interpolation inside a string literal. Braces are counted so
+// that the closing brace of the interpolation can be told apart from the
+// ones belonging to the embedded expression.
+type template struct {
+    multiline bool
+    depth int
 }
 
 // ==============
 // Public methods
 // ==============
 func New(input string) *Tokenizer {
-    tokenizer := &Tokenizer{input: input}
+    return NewFile("", input)
+}
+func NewFile(file string, input string) *Tokenizer {
+    tokenizer := &Tokenizer{file: file, input: input, line: 1}
     tokenizer.readChar()
 
     return tokenizer
@@ -27,22 +45,61 @@ func (self *Tokenizer) GetToken() token.Token {
     if self.char == '#' {
         self.skipComment()
     } 
-    // Identifiers and keywords
-    if isLetter(self.char) && self.char != '_' {
+
+    position := self.currentPosition()
+    token := self.readToken()
+    token.Position = position
+
+    return token
+}
+
+// ===============
+// Private methods
+// ===============
+func (self *Tokenizer) readToken() token.Token {
+    // String interpolations
+    if len(self.templates) > 0 {
+        template := &self.templates[len(self.templates)-1]
+
+        switch {
+        case self.char == '{':
+            template.depth += 1
+        case self.char == '}' && template.depth > 0:
+            template.depth -= 1
+        case self.char == '}':
+            self.readChar()
+            return self.readStringContent(false, template.multiline, token.TEMPLATE_MIDDLE)
+        }
+    }
+    // Raw strings
+    if self.char == 'r' && self.peekCharIs('"') {
+        self.readChar()
+        return self.readString(true)
+    }
+    // Identifiers and keywords. A lone _ is a delimiter, but names like _x
+    // are identifiers.
+    if isLetter(self.char) && (self.char != '_' || isLetter(self.peekChar()) || isDigit(self.peekChar())) {
         return token.NewIdentifier(self.readIdentifier())
     }
     // Numbers
-    if isNumber(self.char) && self.char != '.' {
-        return token.NewNumber(self.readNumber())
+    if isDigit(self.char) {
+        return self.readNumber()
     }
     // Strings
     if self.char == '"' {
-        return token.NewString(self.readString())
+        return self.readString(false)
+    }
+    // Three char delimiters
+    if self.currentCharIs('.') && self.peekCharsAre("..") {
+        self.readChar()
+        self.readChar()
+        self.readChar()
+        return token.NewThreeChar('.', '.', '.')
     }
     // Two char operators
     if (self.currentCharIs('<') && self.peekCharIs('=')) || (self.currentCharIs('>') && self.peekCharIs('=')) {
         char1 := self.char
-        char2 := self.input[self.peekPosition]
+        char2 := self.peekChar()
         self.readChar()
         self.readChar()
         return token.NewTwoChar(char1, char2)
@@ -53,24 +110,45 @@ func (self *Tokenizer) GetToken() token.Token {
 
     return token
 }
-
-// ===============
-// Private methods
-// ===============
 func (self *Tokenizer) readChar() {
+    if self.char == '\n' {
+        self.line += 1
+        self.column = 0
+    }
+    size := 0
     if self.peekPosition >= len(self.input) {
         self.char = 0
     } else {
-        self.char = self.input[self.peekPosition]
+        self.char, size = utf8.DecodeRuneInString(self.input[self.peekPosition:])
     }
     self.position = self.peekPosition
-    self.peekPosition += 1
+    self.peekPosition += size
+    self.column += 1
+}
+func (self *Tokenizer) currentPosition() token.Position {
+    return token.Position{File: self.file, Line: self.line, Column: self.column, Offset: self.position}
 }
-func (self *Tokenizer) currentCharIs(char byte) bool {
+func (self *Tokenizer) currentCharIs(char rune) bool {
     return self.char == char
 }
-func (self *Tokenizer) peekCharIs(char byte) bool {
-    return self.input[self.peekPosition] == char
+func (self *Tokenizer) peekChar() rune {
+    if self.peekPosition >= len(self.input) {
+        return 0
+    }
+    char, _ := utf8.DecodeRuneInString(self.input[self.peekPosition:])
+    return char
+}
+func (self *Tokenizer) peekCharIs(char rune) bool {
+    return self.peekChar() == char
+}
+func (self *Tokenizer) peekCharsAre(chars string) bool {
+    if self.peekPosition >= len(self.input) {
+        return false
+    }
+    return strings.HasPrefix(self.input[self.peekPosition:], chars)
+}
+func (self *Tokenizer) atEnd() bool {
+    return self.position >= len(self.input)
 }
 
 func (self *Tokenizer) skipWhitespace() {
@@ -79,7 +157,7 @@ func (self *Tokenizer) skipWhitespace() {
     }
 }
 func (self *Tokenizer) skipComment() {
-    for self.char != '\n' {
+    for self.char != '\n' && self.char != 0 {
         self.readChar()
     }
     self.skipWhitespace()
@@ -89,37 +167,154 @@ func (self *Tokenizer) skipComment() {
 }
 func (self *Tokenizer) readIdentifier() string {
     position := self.position
-    for isLetter(self.char) || (isNumber(self.char) && self.char != '.') {
+    for isLetter(self.char) || isDigit(self.char) {
         self.readChar()
     }
     return self.input[position:self.position]
 }
-func (self *Tokenizer) readNumber() string {
+func (self *Tokenizer) readNumber() token.Token {
     position := self.position
-    for isNumber(self.char) {
+
+    // Hexadecimal, binary and octal integers
+    if self.char == '0' && strings.ContainsRune("xXbBoO", self.peekChar()) {
         self.readChar()
+        self.readChar()
+        for isHexDigit(self.char) || self.char == '_' {
+            self.readChar()
+        }
+        return token.NewNumber(self.input[position:self.position])
     }
-    return self.input[position:self.position]
+
+    self.readDigits()
+    if self.char == '.' && isDigit(self.peekChar()) {
+        self.readChar()
+        self.readDigits()
+    }
+    if self.char == 'e' || self.char == 'E' {
+        next := self.input[self.peekPosition:]
+        if strings.HasPrefix(next, "+") || strings.HasPrefix(next, "-") {
+            next = next[1:]
+        }
+        if len(next) > 0 && isDigit(rune(next[0])) {
+            self.readChar()
+            if self.char == '+' || self.char == '-' {
+                self.readChar()
+            }
+            self.readDigits()
+        }
+    }
+
+    // A second fractional part, like in 1.2.3
+    if self.char == '.' && isDigit(self.peekChar()) {
+        for isDigit(self.char) || self.char == '.' || self.char == '_' {
+            self.readChar()
+        }
+        return token.NewIllegal("malformed number literal " + self.input[position:self.position])
+    }
+
+    return token.NewNumber(self.input[position:self.position])
 }
-func (self *Tokenizer) readString() string {
+func (self *Tokenizer) readDigits() {
+    for isDigit(self.char) || self.char == '_' {
+        self.readChar()
+    }
+}
+func (self *Tokenizer) readString(raw bool) token.Token {
+    multiline := self.peekCharsAre(`""`)
+    if multiline {
+        self.readChar()
+        self.readChar()
+    }
     self.readChar()
-    position := self.position
-    for self.char != '"' {
+
+    // A newline right after the opening quotes is not part of the string
+    if multiline && self.char == '\n' {
+        self.readChar()
+    }
+
+    return self.readStringContent(raw, multiline, token.TEMPLATE_HEAD)
+}
+// Reads up to the closing quotes or up to the next interpolation, in which
+// case a token of templateType is returned. Reading is resumed after the
+// interpolation with templateType set to TEMPLATE_MIDDLE.
+func (self *Tokenizer) readStringContent(raw bool, multiline bool, templateType int) token.Token {
+    var out strings.Builder
+    var illegal string
+
+    continuation := templateType != token.TEMPLATE_HEAD
+    if continuation {
+        self.templates = self.templates[:len(self.templates)-1]
+    }
+
+    for {
+        if self.atEnd() || (!multiline && self.char == '\n') {
+            return token.NewIllegal("unterminated string literal")
+        }
+        if self.char == '"' && (!multiline || self.peekCharsAre(`""`)) {
+            break
+        }
+        if self.char == '{' && !raw {
+            self.readChar()
+            self.templates = append(self.templates, template{multiline: multiline})
+            if illegal != "" {
+                return token.NewIllegal(illegal)
+            }
+            return token.NewTemplate(templateType, out.String())
+        }
+
+        if self.char == '\\' && !raw {
+            self.readChar()
+            char, ok := escapes[self.char]
+            if !ok && illegal == "" {
+                illegal = "invalid escape sequence \\" + string(self.char)
+            }
+            out.WriteRune(char)
+            self.readChar()
+            continue
+        }
+
+        out.WriteRune(self.char)
+        self.readChar()
+    }
+
+    if multiline {
+        self.readChar()
         self.readChar()
     }
     self.readChar()
-    return self.input[position:self.position-1]
+
+    if illegal != "" {
+        return token.NewIllegal(illegal)
+    }
+    if continuation {
+        return token.NewTemplate(token.TEMPLATE_TAIL, out.String())
+    }
+    return token.NewString(out.String())
 }
 
 // ==============
 // Helper methods
 // ==============
-func isLetter(char byte) bool {
-    return ('a' <= char && char <= 'z') || ('A' <= char && char <= 'Z') || char == '_'
+var escapes = map[rune]rune {
+    'n': '\n',
+    't': '\t',
+    'r': '\r',
+    '0': 0,
+    '"': '"',
+    '\\': '\\',
+    '{': '{',
+    '}': '}',
+}
+
+func isLetter(char rune) bool {
+    return unicode.IsLetter(char) || char == '_'
+}
+func isDigit(char rune) bool {
+    return '0' <= char && char <= '9'
 }
-func isNumber(char byte) bool {
-    return ('0' <= char && char <= '9') || char == '.'
+func isHexDigit(char rune) bool {
+    return isDigit(char) || ('a' <= char && char <= 'f') || ('A' <= char && char <= 'F')
 }
-func isWhitespace(char byte) bool {
+func isWhitespace(char rune) bool {
     return char == ' ' || char == '\t' || char == '\n' || char == '\r'
 }
@@ -709,6 +709,293 @@ func TestBuiltinMethod(t *testing.T) {
 }
 
 
+func TestStrings(t *testing.T) {
+    input := `
+    "tab\tnew\nline"
+    "quote \" and backslash \\"
+    r"C:\raw\n"
+    """
+first
+  "second"
+"""
+    r"""\d+
+\w+"""
+    ""
+    `
+
+    tests := []struct {
+        expectedType int
+        expectedSubtype int
+        expectedLiteral string
+    }{
+        {token.LITERAL, token.STR, "tab\tnew\nline"},
+        {token.LITERAL, token.STR, "quote \" and backslash \\"},
+        {token.LITERAL, token.STR, "C:\\raw\\n"},
+        {token.LITERAL, token.STR, "first\n  \"second\"\n"},
+        {token.LITERAL, token.STR, "\\d+\n\\w+"},
+        {token.LITERAL, token.STR, ""},
+        {token.EOF, token.EOF, "EOF"},
+    }
+
+    runTest(t, input, tests)
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+    input := `"total: {x + 1}!" "{ map("a": 1)("a") } and {"in{n}er"}" r"{x}" "\{x\}"`
+
+    tests := []struct {
+        expectedType int
+        expectedSubtype int
+        expectedLiteral string
+    }{
+        {token.LITERAL, token.TEMPLATE_HEAD, "total: "},
+        {token.IDENTIFIER, token.IDENTIFIER, "x"},
+        {token.OPERATOR, token.PLUS, "+"},
+        {token.LITERAL, token.I64, "1"},
+        {token.LITERAL, token.TEMPLATE_TAIL, "!"},
+
+        {token.LITERAL, token.TEMPLATE_HEAD, ""},
+        {token.TYPE, token.MAP, "map"},
+        {token.DELIMITER, token.LPAREN, "("},
+        {token.LITERAL, token.STR, "a"},
+        {token.DELIMITER, token.COLON, ":"},
+        {token.LITERAL, token.I64, "1"},
+        {token.DELIMITER, token.RPAREN, ")"},
+        {token.DELIMITER, token.LPAREN, "("},
+        {token.LITERAL, token.STR, "a"},
+        {token.DELIMITER, token.RPAREN, ")"},
+        {token.LITERAL, token.TEMPLATE_MIDDLE, " and "},
+        {token.LITERAL, token.TEMPLATE_HEAD, "in"},
+        {token.IDENTIFIER, token.IDENTIFIER, "n"},
+        {token.LITERAL, token.TEMPLATE_TAIL, "er"},
+        {token.LITERAL, token.TEMPLATE_TAIL, ""},
+
+        {token.LITERAL, token.STR, "{x}"},
+        {token.LITERAL, token.STR, "{x}"},
+        {token.EOF, token.EOF, "EOF"},
+    }
+
+    runTest(t, input, tests)
+}
+
+func TestIllegalStrings(t *testing.T) {
+    tests := []struct {
+        input string
+        expectedLiteral string
+        expectedColumn int
+    }{
+        {`let x be "abc`, "unterminated string literal", 10},
+        {"let x be \"abc\nlet y be 5", "unterminated string literal", 10},
+        {`let x be """abc"`, "unterminated string literal", 10},
+        {`let x be "a\qb"`, "invalid escape sequence \\q", 10},
+    }
+
+    for i, tt := range tests {
+        tokenizer := New(tt.input)
+        for j := 0; j < 3; j++ {
+            tokenizer.GetToken()
+        }
+
+        token := tokenizer.GetToken()
+        if token.Literal != tt.expectedLiteral {
+            t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, token.Literal)
+        }
+        if token.Position.Column != tt.expectedColumn {
+            t.Fatalf("tests[%d] - column wrong. expected=%d, got=%d", i, tt.expectedColumn, token.Position.Column)
+        }
+    }
+}
+
+func TestUnicode(t *testing.T) {
+    input := `let café be "añejo 김치" + größe`
+
+    tests := []struct {
+        expectedType int
+        expectedSubtype int
+        expectedLiteral string
+    }{
+        {token.KEYWORD, token.LET, "let"},
+        {token.IDENTIFIER, token.IDENTIFIER, "café"},
+        {token.KEYWORD, token.BE, "be"},
+        {token.LITERAL, token.STR, "añejo 김치"},
+        {token.OPERATOR, token.PLUS, "+"},
+        {token.IDENTIFIER, token.IDENTIFIER, "größe"},
+        {token.EOF, token.EOF, "EOF"},
+    }
+
+    runTest(t, input, tests)
+
+    tokenizer := New(input)
+    for i := 0; i < 5; i++ {
+        tokenizer.GetToken()
+    }
+    if tok := tokenizer.GetToken(); tok.Position.Column != 26 || tok.Position.Offset != 31 {
+        t.Fatalf("position wrong. expected column=26 offset=31, got column=%d offset=%d", tok.Position.Column, tok.Position.Offset)
+    }
+}
+
+func TestPositions(t *testing.T) {
+    input := "let x be 5\n  mut x to \"a\""
+
+    tests := []struct {
+        expectedLiteral string
+        expectedLine int
+        expectedColumn int
+        expectedOffset int
+    }{
+        {"let", 1, 1, 0},
+        {"x", 1, 5, 4},
+        {"be", 1, 7, 6},
+        {"5", 1, 10, 9},
+        {"mut", 2, 3, 13},
+        {"x", 2, 7, 17},
+        {"to", 2, 9, 19},
+        {"a", 2, 12, 22},
+    }
+
+    tokenizer := NewFile("main.kimchi", input)
+
+    for i, tt := range tests {
+        token := tokenizer.GetToken()
+
+        if token.Literal != tt.expectedLiteral {
+            t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, token.Literal)
+        }
+        if token.Position.File != "main.kimchi" {
+            t.Fatalf("tests[%d] - file wrong. expected=%q, got=%q", i, "main.kimchi", token.Position.File)
+        }
+        if token.Position.Line != tt.expectedLine || token.Position.Column != tt.expectedColumn {
+            t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d", i, tt.expectedLine, tt.expectedColumn, token.Position.Line, token.Position.Column)
+        }
+        if token.Position.Offset != tt.expectedOffset {
+            t.Fatalf("tests[%d] - offset wrong. expected=%d, got=%d", i, tt.expectedOffset, token.Position.Offset)
+        }
+    }
+}
+
+func TestTrailingComment(t *testing.T) {
+    tokenizer := New("let x be 5 # no newline")
+
+    for i := 0; i < 4; i++ {
+        tokenizer.GetToken()
+    }
+
+    if tok := tokenizer.GetToken(); tok.Type != token.EOF {
+        t.Fatalf("expected EOF after trailing comment. got=%q", tok.Literal)
+    }
+}
+
+func TestNumbers(t *testing.T) {
+    input := `0xff 0b1010 0o17 1_000_000 1e9 2.5E-3 1.5 5.as_str() 1.2.3`
+
+    tests := []struct {
+        expectedType int
+        expectedSubtype int
+        expectedLiteral string
+    }{
+        {token.LITERAL, token.I64, "0xff"},
+        {token.LITERAL, token.I64, "0b1010"},
+        {token.LITERAL, token.I64, "0o17"},
+        {token.LITERAL, token.I64, "1_000_000"},
+        {token.LITERAL, token.F64, "1e9"},
+        {token.LITERAL, token.F64, "2.5E-3"},
+        {token.LITERAL, token.F64, "1.5"},
+        {token.LITERAL, token.I64, "5"},
+        {token.DELIMITER, token.DOT, "."},
+        {token.IDENTIFIER, token.IDENTIFIER, "as_str"},
+        {token.DELIMITER, token.LPAREN, "("},
+        {token.DELIMITER, token.RPAREN, ")"},
+        {token.ILLEGAL, token.ILLEGAL, "malformed number literal 1.2.3"},
+        {token.EOF, token.EOF, "EOF"},
+    }
+
+    runTest(t, input, tests)
+}
+
+func TestEllipsis(t *testing.T) {
+    input := `list(first, ...rest) x.y`
+
+    tests := []struct {
+        expectedType int
+        expectedSubtype int
+        expectedLiteral string
+    }{
+        {token.TYPE, token.LIST, "list"},
+        {token.DELIMITER, token.LPAREN, "("},
+        {token.IDENTIFIER, token.IDENTIFIER, "first"},
+        {token.DELIMITER, token.COMMA, ","},
+        {token.DELIMITER, token.ELLIPSIS, "..."},
+        {token.IDENTIFIER, token.IDENTIFIER, "rest"},
+        {token.DELIMITER, token.RPAREN, ")"},
+        {token.IDENTIFIER, token.IDENTIFIER, "x"},
+        {token.DELIMITER, token.DOT, "."},
+        {token.IDENTIFIER, token.IDENTIFIER, "y"},
+        {token.EOF, token.EOF, "EOF"},
+    }
+
+    runTest(t, input, tests)
+}
+
+func TestUseStatement(t *testing.T) {
+    input := `use "lib/geom" let _x be _, _2`
+
+    tests := []struct {
+        expectedType int
+        expectedSubtype int
+        expectedLiteral string
+    }{
+        {token.KEYWORD, token.USE, "use"},
+        {token.LITERAL, token.STR, "lib/geom"},
+        {token.KEYWORD, token.LET, "let"},
+        {token.IDENTIFIER, token.IDENTIFIER, "_x"},
+        {token.KEYWORD, token.BE, "be"},
+        {token.DELIMITER, token.UNDERSCORE, "_"},
+        {token.DELIMITER, token.COMMA, ","},
+        {token.IDENTIFIER, token.IDENTIFIER, "_2"},
+        {token.EOF, token.EOF, "EOF"},
+    }
+
+    runTest(t, input, tests)
+}
+
+func TestTypeParameters(t *testing.T) {
+    input := `let first be fn[T](xs: list(T)): T { return xs(0) }`
+
+    tests := []struct {
+        expectedType int
+        expectedSubtype int
+        expectedLiteral string
+    }{
+        {token.KEYWORD, token.LET, "let"},
+        {token.IDENTIFIER, token.IDENTIFIER, "first"},
+        {token.KEYWORD, token.BE, "be"},
+        {token.TYPE, token.FN, "fn"},
+        {token.DELIMITER, token.LBRACKET, "["},
+        {token.IDENTIFIER, token.IDENTIFIER, "T"},
+        {token.DELIMITER, token.RBRACKET, "]"},
+        {token.DELIMITER, token.LPAREN, "("},
+        {token.IDENTIFIER, token.IDENTIFIER, "xs"},
+        {token.DELIMITER, token.COLON, ":"},
+        {token.TYPE, token.LIST, "list"},
+        {token.DELIMITER, token.LPAREN, "("},
+        {token.IDENTIFIER, token.IDENTIFIER, "T"},
+        {token.DELIMITER, token.RPAREN, ")"},
+        {token.DELIMITER, token.RPAREN, ")"},
+        {token.DELIMITER, token.COLON, ":"},
+        {token.IDENTIFIER, token.IDENTIFIER, "T"},
+        {token.DELIMITER, token.LBRACE, "{"},
+        {token.KEYWORD, token.RETURN, "return"},
+        {token.IDENTIFIER, token.IDENTIFIER, "xs"},
+        {token.DELIMITER, token.LPAREN, "("},
+        {token.LITERAL, token.I64, "0"},
+        {token.DELIMITER, token.RPAREN, ")"},
+        {token.DELIMITER, token.RBRACE, "}"},
+        {token.EOF, token.EOF, "EOF"},
+    }
+
+    runTest(t, input, tests)
+}
+
 // =======
 // Helpers
 // =======